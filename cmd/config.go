@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v2"
+)
+
+// fileConfig is the content of the configuration file.
+type fileConfig struct {
+	Defaults map[string]any      `json:"defaults" yaml:"defaults"` // flag values, by long flag name
+	Theme    map[string]string   `json:"theme" yaml:"theme"`       // see theme.SasqTheme.With
+	Keys     map[string][]string `json:"keys" yaml:"keys"`         // see ui.CheckKeys
+	Profiles map[string]profile  `json:"profiles" yaml:"profiles"`
+}
+
+// profile is a named watch selected with -p.
+type profile struct {
+	Command  string         `json:"command" yaml:"command"`   // run when no command is given
+	Commands []string       `json:"commands" yaml:"commands"` // more commands, as with -c
+	Interval uint           `json:"interval" yaml:"interval"` // seconds
+	Diff     string         `json:"diff" yaml:"diff"`         // diff, permdiff, heatmap or delta
+	Ignore   []string       `json:"ignore" yaml:"ignore"`     // as with --ignore
+	Flags    map[string]any `json:"flags" yaml:"flags"`       // other flag values
+}
+
+// configNames are the names the configuration file is looked for under, in
+// order of preference.
+var configNames = []string{"config.json", "config.yaml", "config.yml"}
+
+// configPath returns where the configuration file is looked for: the first
+// of configNames found in $XDG_CONFIG_HOME/sasqwatch, or ~/.config when
+// unset, config.json when there is none.
+func configPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	dir = filepath.Join(dir, "sasqwatch")
+	for _, name := range configNames {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return filepath.Join(dir, name)
+		}
+	}
+	return filepath.Join(dir, configNames[0])
+}
+
+// loadConfig reads the configuration file at path, as YAML when its
+// extension is .yaml or .yml and as JSON otherwise. A missing file is an
+// empty configuration unless required.
+func loadConfig(path string, required bool) (fileConfig, error) {
+	var cfg fileConfig
+	if path == "" {
+		return cfg, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && !required {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	unmarshal := json.Unmarshal
+	if ext := filepath.Ext(path); ext == ".yaml" || ext == ".yml" {
+		unmarshal = yaml.Unmarshal
+	}
+	if err := unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// applyConfig sets the flags of fs left unset on the command line from the
+// profile named name, if any, then from the defaults of cfg. It returns the
+// command of the profile.
+func applyConfig(fs *pflag.FlagSet, cfg fileConfig, name string) (string, error) {
+	var command string
+	if name != "" {
+		p, ok := cfg.Profiles[name]
+		if !ok {
+			return "", fmt.Errorf("unknown profile %q", name)
+		}
+		values := map[string]any{}
+		for flag, v := range p.Flags {
+			values[flag] = v
+		}
+		if len(p.Commands) > 0 {
+			values["command"] = anySlice(p.Commands)
+		}
+		if p.Interval > 0 {
+			values["interval"] = float64(p.Interval)
+		}
+		if len(p.Ignore) > 0 {
+			values["ignore"] = anySlice(p.Ignore)
+		}
+		switch p.Diff {
+		case "":
+		case "diff", "permdiff", "heatmap", "delta":
+			values[p.Diff] = true
+		default:
+			return "", fmt.Errorf("profile %q: invalid diff %q: expected 'diff', 'permdiff', 'heatmap' or 'delta'", name, p.Diff)
+		}
+		if err := setFlags(fs, values); err != nil {
+			return "", fmt.Errorf("profile %q: %w", name, err)
+		}
+		command = p.Command
+	}
+	if err := setFlags(fs, cfg.Defaults); err != nil {
+		return "", fmt.Errorf("defaults: %w", err)
+	}
+	return command, nil
+}
+
+// diffFlags select the diff mode. They are one setting: a layer setting any
+// of them overrides all of them in the layers below.
+var diffFlags = []string{"diff", "permdiff", "heatmap", "delta"}
+
+// profileArgs returns the command to run: args, or the command of the
+// profile when there are none. The latter is a shell command line, which
+// cannot be run directly with -x.
+func profileArgs(args []string, profileCmd string, exec bool) ([]string, error) {
+	if len(args) > 0 || profileCmd == "" {
+		return args, nil
+	}
+	if exec {
+		return nil, fmt.Errorf("its command is run by the shell and cannot be used with -x")
+	}
+	return []string{profileCmd}, nil
+}
+
+// setFlags sets the flags of values left unset, a list setting a repeatable
+// flag once per element. The diff flags are left alone when any of them is
+// already set, and values may enable only one of them.
+func setFlags(fs *pflag.FlagSet, values map[string]any) error {
+	var modes []string
+	for _, name := range diffFlags {
+		if v, ok := values[name]; ok && v != false {
+			modes = append(modes, name)
+		}
+	}
+	if len(modes) > 1 {
+		return fmt.Errorf("several diff modes: %s", strings.Join(modes, ", "))
+	}
+	diffSet := slices.ContainsFunc(diffFlags, func(name string) bool {
+		return fs.Changed(name)
+	})
+	for name, v := range values {
+		f := fs.Lookup(name)
+		if f == nil {
+			return fmt.Errorf("unknown flag %q", name)
+		}
+		if f.Changed || diffSet && slices.Contains(diffFlags, name) {
+			continue
+		}
+		list, ok := v.([]any)
+		if !ok {
+			list = []any{v}
+		}
+		for _, e := range list {
+			if err := fs.Set(name, flagValue(e)); err != nil {
+				return fmt.Errorf("flag %q: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// flagValue formats a JSON value as a command line value.
+func flagValue(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func anySlice(s []string) []any {
+	out := make([]any, len(s))
+	for i, v := range s {
+		out[i] = v
+	}
+	return out
+}
@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func newConfigFlags(t *testing.T, args ...string) *pflag.FlagSet {
+	t.Helper()
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	fs.UintP("interval", "n", 2, "")
+	fs.Bool("diff", false, "")
+	fs.Bool("permdiff", false, "")
+	fs.Bool("heatmap", false, "")
+	fs.Bool("delta", false, "")
+	fs.Bool("wrap", false, "")
+	fs.String("layout", "rows", "")
+	fs.StringArray("command", nil, "")
+	fs.StringArray("ignore", nil, "")
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	return fs
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	missing := filepath.Join(dir, "missing.json")
+	if _, err := loadConfig(missing, false); err != nil {
+		t.Fatalf("expected a missing optional file to be ignored, got %v", err)
+	}
+	if _, err := loadConfig(missing, true); err == nil {
+		t.Fatalf("expected an error for a missing required file")
+	}
+
+	path := filepath.Join(dir, "config.json")
+	os.WriteFile(path, []byte(`{"defaults": {"interval": 5}, "profiles": {"pods": {"command": "kubectl get pods"}}}`), 0o600)
+	cfg, err := loadConfig(path, true)
+	if err != nil || cfg.Defaults["interval"] != 5.0 || cfg.Profiles["pods"].Command != "kubectl get pods" {
+		t.Fatalf("unexpected configuration %+v (%v)", cfg, err)
+	}
+
+	os.WriteFile(path, []byte(`{"defaults": `), 0o600)
+	if _, err := loadConfig(path, false); err == nil {
+		t.Fatalf("expected an error for invalid JSON")
+	}
+}
+
+func TestLoadConfig_YAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	os.WriteFile(path, []byte("defaults:\n  interval: 5\n  env: [LC_ALL=C]\nkeys:\n  pause: [p]\nprofiles:\n  pods:\n    command: kubectl get pods\n    diff: heatmap\n"), 0o600)
+	cfg, err := loadConfig(path, true)
+	if err != nil || cfg.Profiles["pods"].Diff != "heatmap" || cfg.Keys["pause"][0] != "p" {
+		t.Fatalf("unexpected configuration %+v (%v)", cfg, err)
+	}
+
+	fs := newConfigFlags(t)
+	fs.StringArray("env", nil, "")
+	if _, err := applyConfig(fs, cfg, "pods"); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := fs.GetUint("interval"); v != 5 {
+		t.Fatalf("expected the interval of the defaults, got %d", v)
+	}
+	if v, _ := fs.GetStringArray("env"); !slices.Equal(v, []string{"LC_ALL=C"}) {
+		t.Fatalf("expected the env of the defaults, got %q", v)
+	}
+}
+
+func TestConfigPath(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	if got := configPath(); got != filepath.Join(dir, "sasqwatch", "config.json") {
+		t.Fatalf("unexpected path %q", got)
+	}
+	os.MkdirAll(filepath.Join(dir, "sasqwatch"), 0o700)
+	os.WriteFile(filepath.Join(dir, "sasqwatch", "config.yml"), nil, 0o600)
+	if got := configPath(); got != filepath.Join(dir, "sasqwatch", "config.yml") {
+		t.Fatalf("expected the YAML file, got %q", got)
+	}
+}
+
+func TestApplyConfig_Precedence(t *testing.T) {
+	cfg := fileConfig{
+		Defaults: map[string]any{"interval": 10.0, "wrap": true, "layout": "columns"},
+		Profiles: map[string]profile{"pods": {
+			Command:  "kubectl get pods",
+			Commands: []string{"kubectl get events"},
+			Interval: 5,
+			Diff:     "heatmap",
+			Ignore:   []string{`\d+s`, `\d+m`},
+			Flags:    map[string]any{"layout": "tabs"},
+		}},
+	}
+	fs := newConfigFlags(t, "--layout", "rows")
+	command, err := applyConfig(fs, cfg, "pods")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if command != "kubectl get pods" {
+		t.Fatalf("expected the command of the profile, got %q", command)
+	}
+	if v, _ := fs.GetUint("interval"); v != 5 {
+		t.Fatalf("expected the profile to override the defaults, got interval %d", v)
+	}
+	if v, _ := fs.GetString("layout"); v != "rows" {
+		t.Fatalf("expected the command line to override the profile, got layout %q", v)
+	}
+	if v, _ := fs.GetBool("wrap"); !v {
+		t.Fatalf("expected the defaults to apply")
+	}
+	if v, _ := fs.GetBool("heatmap"); !v {
+		t.Fatalf("expected the diff mode of the profile")
+	}
+	if v, _ := fs.GetStringArray("ignore"); !slices.Equal(v, []string{`\d+s`, `\d+m`}) {
+		t.Fatalf("expected the ignore patterns of the profile, got %q", v)
+	}
+	if v, _ := fs.GetStringArray("command"); !slices.Equal(v, []string{"kubectl get events"}) {
+		t.Fatalf("expected the commands of the profile, got %q", v)
+	}
+}
+
+func TestApplyConfig_DiffModeIsOneSetting(t *testing.T) {
+	tests := map[string]struct {
+		args []string
+		cfg  fileConfig
+		want string
+	}{
+		"profile over defaults": {
+			cfg: fileConfig{
+				Defaults: map[string]any{"diff": true},
+				Profiles: map[string]profile{"p": {Diff: "heatmap"}},
+			},
+			want: "heatmap",
+		},
+		"command line over profile": {
+			args: []string{"--delta"},
+			cfg:  fileConfig{Profiles: map[string]profile{"p": {Diff: "diff"}}},
+			want: "delta",
+		},
+		"defaults when unset": {
+			cfg:  fileConfig{Defaults: map[string]any{"permdiff": true}, Profiles: map[string]profile{"p": {}}},
+			want: "permdiff",
+		},
+	}
+	for name, tt := range tests {
+		fs := newConfigFlags(t, tt.args...)
+		if _, err := applyConfig(fs, tt.cfg, "p"); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		for _, flag := range diffFlags {
+			if v, _ := fs.GetBool(flag); v != (flag == tt.want) {
+				t.Fatalf("%s: expected only --%s, got --%s=%v", name, tt.want, flag, v)
+			}
+		}
+	}
+}
+
+func TestProfileArgs(t *testing.T) {
+	if args, err := profileArgs(nil, "kubectl get pods", false); err != nil || !slices.Equal(args, []string{"kubectl get pods"}) {
+		t.Fatalf("expected the command of the profile, got %q (%v)", args, err)
+	}
+	if args, err := profileArgs([]string{"ls", "-l"}, "kubectl get pods", true); err != nil || !slices.Equal(args, []string{"ls", "-l"}) {
+		t.Fatalf("expected the arguments to win over the profile, got %q (%v)", args, err)
+	}
+	if _, err := profileArgs(nil, "kubectl get pods", true); err == nil {
+		t.Fatalf("expected an error for -x with the command of a profile")
+	}
+}
+
+func TestApplyConfig_Errors(t *testing.T) {
+	tests := map[string]struct {
+		cfg     fileConfig
+		profile string
+	}{
+		"unknown profile": {fileConfig{}, "nope"},
+		"unknown flag":    {fileConfig{Defaults: map[string]any{"nope": true}}, ""},
+		"invalid value":   {fileConfig{Defaults: map[string]any{"interval": "soon"}}, ""},
+		"invalid diff":    {fileConfig{Profiles: map[string]profile{"p": {Diff: "colors"}}}, "p"},
+		"profile diff modes": {
+			fileConfig{Profiles: map[string]profile{"p": {Diff: "diff", Flags: map[string]any{"heatmap": true}}}}, "p",
+		},
+		"default diff modes": {fileConfig{Defaults: map[string]any{"diff": true, "delta": true}}, ""},
+	}
+	for name, tt := range tests {
+		if _, err := applyConfig(newConfigFlags(t), tt.cfg, tt.profile); err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// commandEnv returns the environment given with --env-file and --env, in
+// that order so --env wins when a variable is set by both.
+func commandEnv(envFile string, env []string) ([]string, error) {
+	var vars []string
+	if envFile != "" {
+		var err error
+		if vars, err = readEnvFile(envFile); err != nil {
+			return nil, err
+		}
+	}
+	for _, kv := range env {
+		if !strings.Contains(kv, "=") {
+			return nil, fmt.Errorf("invalid --env value %q: expected KEY=VALUE", kv)
+		}
+		vars = append(vars, kv)
+	}
+	return vars, nil
+}
+
+// readEnvFile parses a file of KEY=VALUE lines. Empty lines and lines
+// starting with # are skipped, an optional "export " prefix is allowed and
+// values may be surrounded by single or double quotes.
+func readEnvFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read env file: %w", err)
+	}
+	defer f.Close()
+
+	var vars []string
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", path, n)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		vars = append(vars, key+"="+value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read env file: %w", err)
+	}
+	return vars, nil
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestCommandEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "env")
+	content := "# comment\n\nexport A=1\nB = \"two words\"\nC='x'\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := commandEnv(path, []string{"A=override"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"A=1", "B=two words", "C=x", "A=override"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestCommandEnv_Invalid(t *testing.T) {
+	if _, err := commandEnv("", []string{"NOVALUE"}); err == nil {
+		t.Fatalf("expected an error for --env without =")
+	}
+	path := filepath.Join(t.TempDir(), "env")
+	if err := os.WriteFile(path, []byte("A=1\nbroken\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := commandEnv(path, nil); err == nil {
+		t.Fatalf("expected an error for a line without =")
+	}
+	if _, err := commandEnv(filepath.Join(t.TempDir(), "missing"), nil); err == nil {
+		t.Fatalf("expected an error for a missing file")
+	}
+}
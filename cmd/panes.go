@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/fabio42/sasqwatch/ui"
+)
+
+// paneConfigs returns the configuration of every watched command: the one
+// given as arguments, if any, then one per -c flag. They share base, except
+// for the command and the interval.
+func paneConfigs(base ui.Config, args, commands []string) ([]ui.Config, error) {
+	var cfgs []ui.Config
+	if len(args) > 0 {
+		cfgs = append(cfgs, base)
+	}
+	for _, c := range commands {
+		interval, command, err := ui.ParseCommandSpec(c, base.Interval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -c %q: %w", c, err)
+		}
+		cfg := base
+		cfg.Cmd, cfg.Exec, cfg.Interval = command, nil, interval
+		cfgs = append(cfgs, cfg)
+	}
+	return cfgs, nil
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fabio42/sasqwatch/ui"
+)
+
+func TestPaneConfigs(t *testing.T) {
+	base := ui.Config{Cmd: "stat my file", Exec: []string{"stat", "my file"}, Interval: 2 * time.Second, History: 10}
+	cfgs, err := paneConfigs(base, []string{"stat", "my file"}, []string{"5:uptime"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfgs) != 2 || cfgs[0].Cmd != "stat my file" || len(cfgs[0].Exec) != 2 {
+		t.Fatalf("expected the arguments as the first pane, got %+v", cfgs)
+	}
+	if c := cfgs[1]; c.Cmd != "uptime" || c.Exec != nil || c.Interval != 5*time.Second || c.History != 10 {
+		t.Fatalf("expected -c to override the command and the interval only, got %+v", c)
+	}
+
+	if _, err := paneConfigs(base, nil, []string{"5: "}); err == nil {
+		t.Fatalf("expected an error for an empty command")
+	}
+
+	cfgs, _ = paneConfigs(base, nil, []string{"uptime"})
+	if len(cfgs) != 1 || cfgs[0].Cmd != "uptime" {
+		t.Fatalf("expected a single pane from -c, got %+v", cfgs)
+	}
+}
@@ -2,12 +2,16 @@ package cmd
 
 import (
 	"fmt"
+	"math"
 	"os"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/fabio42/sasqwatch/ui"
 	"github.com/fabio42/sasqwatch/ui/theme"
+	"github.com/fabio42/sasqwatch/viewport"
 
 	tea "charm.land/bubbletea/v2"
 	"github.com/rs/zerolog/log"
@@ -16,24 +20,67 @@ import (
 
 var (
 	rootFlags = struct {
-		chgExit  bool
-		debug    bool
-		diff     bool
-		errExit  bool
-		permDiff bool
-		pty      bool
-		interval uint
-		records  uint
-		title    string
+		anchor     string
+		clipboard  string
+		commands   []string
+		config     string
+		cwd        string
+		env        []string
+		envFile    string
+		ignore     []string
+		maxOutput  string
+		keepOutput string
+		layout     string
+		profile    string
+		stdin      string
+		chgExit    bool
+		debug      bool
+		delta      bool
+		diff       bool
+		errExit    bool
+		exec       bool
+		heatmap    bool
+		lineNums   bool
+		mouse      bool
+		permDiff   bool
+		pty        bool
+		resizeRun  bool
+		stream     bool
+		wrap       bool
+		freezeCols uint
+		freezeRows uint
+		interval   uint
+		records    uint
+		shell      string
+		title      string
 	}{}
 
 	rootCmd = &cobra.Command{
 		Use:   "sasqwatch [flags] command",
 		Short: "sasqwatch",
 		Long:  "sasqwatch is a tool to execute a program periodically, showing output fullscreen.",
-		Args:  cobra.MinimumNArgs(1),
+		Args:  cobra.ArbitraryArgs,
 
 		RunE: func(cmd *cobra.Command, args []string) error {
+			path, required := rootFlags.config, true
+			if path == "" {
+				path, required = configPath(), false
+			}
+			file, err := loadConfig(path, required)
+			if err != nil {
+				return fmt.Errorf("failed to read the configuration: %w", err)
+			}
+			profileCmd, err := applyConfig(cmd.Flags(), file, rootFlags.profile)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			if args, err = profileArgs(args, profileCmd, rootFlags.exec); err != nil {
+				return fmt.Errorf("profile %q: %w", rootFlags.profile, err)
+			}
+			if len(args) == 0 && len(rootFlags.commands) == 0 {
+				return fmt.Errorf("requires a command, as arguments, with -c or from a profile")
+			}
+
 			if err := setLogger(rootFlags.debug); err != nil {
 				return fmt.Errorf("failed to configure logger: %w", err)
 			}
@@ -48,20 +95,109 @@ var (
 				}
 			}
 
+			scroll, err := parseScrollMode(rootFlags.anchor)
+			if err != nil {
+				return err
+			}
+
+			clip, err := ui.NewClipboard(rootFlags.clipboard)
+			if err != nil {
+				return err
+			}
+
+			shell := os.ExpandEnv(rootFlags.shell)
+			var argv []string
+			if rootFlags.exec {
+				argv = args
+			}
+
+			maxOutput, err := parseSize(rootFlags.maxOutput)
+			if err != nil {
+				return fmt.Errorf("invalid --max-output %q: %w", rootFlags.maxOutput, err)
+			}
+			switch rootFlags.keepOutput {
+			case ui.KeepHead, ui.KeepTail, ui.KeepBoth:
+			default:
+				return fmt.Errorf("invalid --max-output-keep value %q: expected 'head', 'tail' or 'both'", rootFlags.keepOutput)
+			}
+
+			var ignore []*regexp.Regexp
+			for _, pattern := range rootFlags.ignore {
+				re, err := regexp.Compile(pattern)
+				if err != nil {
+					return fmt.Errorf("invalid --ignore %q: %w", pattern, err)
+				}
+				ignore = append(ignore, re)
+			}
+			sasqTheme, err := theme.DefaultTheme().With(file.Theme)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			if err := ui.CheckKeys(file.Keys); err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+
+			env, err := commandEnv(rootFlags.envFile, rootFlags.env)
+			if err != nil {
+				return err
+			}
+			if rootFlags.cwd != "" {
+				if fi, err := os.Stat(rootFlags.cwd); err != nil || !fi.IsDir() {
+					return fmt.Errorf("invalid --cwd %q: not a directory", rootFlags.cwd)
+				}
+			}
+			if rootFlags.stdin != "" {
+				if err := checkReadable(rootFlags.stdin); err != nil {
+					return fmt.Errorf("invalid --stdin: %w", err)
+				}
+			}
+
 			cfg := ui.Config{
-				Interval: time.Second * time.Duration(rootFlags.interval),
-				History:  int(rootFlags.records),
-				HostName: hostname,
-				Cmd:      strings.Join(args, " "),
-				ChgExit:  rootFlags.chgExit,
-				Diff:     rootFlags.diff,
-				ErrExit:  rootFlags.errExit,
-				PermDiff: rootFlags.permDiff,
-				Pty:      rootFlags.pty,
-				Theme:    theme.DefaultTheme(),
-			}
-
-			m := ui.NewModel(cfg)
+				Interval:   time.Second * time.Duration(rootFlags.interval),
+				History:    int(rootFlags.records),
+				HostName:   hostname,
+				Cmd:        strings.Join(args, " "),
+				Shell:      shell,
+				Exec:       argv,
+				Env:        env,
+				Dir:        rootFlags.cwd,
+				Stdin:      rootFlags.stdin,
+				MaxOutput:  maxOutput,
+				KeepOutput: rootFlags.keepOutput,
+				ChgExit:    rootFlags.chgExit,
+				Diff:       rootFlags.diff,
+				ErrExit:    rootFlags.errExit,
+				Heatmap:    rootFlags.heatmap,
+				Delta:      rootFlags.delta,
+				PermDiff:   rootFlags.permDiff,
+				Pty:        rootFlags.pty,
+				ResizeRun:  rootFlags.resizeRun,
+				Stream:     rootFlags.stream,
+				Wrap:       rootFlags.wrap,
+				LineNums:   rootFlags.lineNums,
+				Mouse:      rootFlags.mouse,
+				Scroll:     scroll,
+				FreezeRows: int(rootFlags.freezeRows),
+				FreezeCols: int(rootFlags.freezeCols),
+				Ignore:     ignore,
+				Theme:      sasqTheme,
+				Keys:       file.Keys,
+				Clip:       clip,
+			}
+
+			cfgs, err := paneConfigs(cfg, args, rootFlags.commands)
+			if err != nil {
+				return err
+			}
+			switch rootFlags.layout {
+			case ui.LayoutRows, ui.LayoutColumns, ui.LayoutTabs:
+			default:
+				return fmt.Errorf("invalid --layout value %q: expected 'rows', 'columns' or 'tabs'", rootFlags.layout)
+			}
+			var m tea.Model = ui.NewModel(cfgs[0])
+			if len(cfgs) > 1 || rootFlags.layout == ui.LayoutTabs {
+				m = ui.NewMulti(cfgs, rootFlags.layout)
+			}
 			if _, err := tea.NewProgram(m).Run(); err != nil {
 				return fmt.Errorf("program error: %w", err)
 			}
@@ -71,17 +207,99 @@ var (
 )
 
 func init() {
+	rootCmd.PersistentFlags().StringVarP(&rootFlags.anchor, "anchor", "A", "", "Keep the scroll position across refreshes: 'line' keeps the same content line in view, 'tail' follows the bottom")
+	rootCmd.PersistentFlags().StringVar(&rootFlags.clipboard, "clipboard", "system", "Clipboard used to copy: 'system' (falls back to osc52), 'osc52' through the terminal, 'file:PATH' or 'command:CMD'")
+	rootCmd.PersistentFlags().StringArrayVarP(&rootFlags.commands, "command", "c", nil, "Watch another command, as [INTERVAL:]COMMAND, in its own pane; can be repeated")
+	rootCmd.PersistentFlags().StringVar(&rootFlags.config, "config", "", "Read the configuration from this JSON or YAML file (default $XDG_CONFIG_HOME/sasqwatch/config.{json,yaml,yml})")
 	rootCmd.PersistentFlags().BoolVarP(&rootFlags.chgExit, "chgexit", "g", false, "Exit when output from command changes")
+	rootCmd.PersistentFlags().StringVar(&rootFlags.cwd, "cwd", "", "Run the command in this directory")
 	rootCmd.PersistentFlags().BoolVarP(&rootFlags.debug, "debug", "D", false, "Enable debug log")
+	rootCmd.PersistentFlags().BoolVarP(&rootFlags.delta, "delta", "a", false, "Annotate numbers that changed between successive updates with their delta and rate per second")
 	rootCmd.PersistentFlags().BoolVarP(&rootFlags.diff, "diff", "d", false, "Highlight the differences between successive updates")
+	rootCmd.PersistentFlags().StringArrayVar(&rootFlags.env, "env", nil, "Set an environment variable of the command, as KEY=VALUE; can be repeated")
+	rootCmd.PersistentFlags().StringVar(&rootFlags.envFile, "env-file", "", "Read environment variables of the command from a file of KEY=VALUE lines")
 	rootCmd.PersistentFlags().BoolVarP(&rootFlags.errExit, "errexit", "e", false, "Exit if command has a non-zero exit")
+	rootCmd.PersistentFlags().BoolVarP(&rootFlags.exec, "exec", "x", false, "Run the command directly with its arguments instead of passing it to a shell")
+	rootCmd.PersistentFlags().BoolVarP(&rootFlags.heatmap, "heatmap", "H", false, "Highlight the differences with a heatmap fading by how recently they changed")
+	rootCmd.PersistentFlags().StringArrayVar(&rootFlags.ignore, "ignore", nil, "Do not record a new output when only text matching this regular expression changed; can be repeated")
+	rootCmd.PersistentFlags().StringVar(&rootFlags.layout, "layout", ui.LayoutRows, "Layout of the panes when several commands are watched: 'rows', 'columns' or 'tabs'")
+	rootCmd.PersistentFlags().BoolVarP(&rootFlags.lineNums, "line-numbers", "N", false, "Show line numbers in a gutter")
+	rootCmd.PersistentFlags().StringVar(&rootFlags.maxOutput, "max-output", "10M", "Keep at most this much output per run, e.g. 512K or 10M; 0 keeps everything")
+	rootCmd.PersistentFlags().StringVar(&rootFlags.keepOutput, "max-output-keep", ui.KeepBoth, "Part of the output kept past --max-output: 'head', 'tail' or 'both'")
+	rootCmd.PersistentFlags().BoolVarP(&rootFlags.mouse, "mouse", "M", false, "Enable the mouse: wheel scrolling, clickable status bar and drag to copy")
 	rootCmd.PersistentFlags().BoolVarP(&rootFlags.permDiff, "permdiff", "P", false, "Highlight the differences between successive updates since the first iteration")
-	rootCmd.PersistentFlags().BoolVarP(&rootFlags.pty, "pty", "t", false, "Run the command on a pseudo-terminal (TTY) so terminal-aware tools format correctly; may emit raw escape codes for screen-control programs")
+	rootCmd.PersistentFlags().StringVarP(&rootFlags.profile, "profile", "p", "", "Use the named profile of the configuration file")
+	rootCmd.PersistentFlags().BoolVarP(&rootFlags.pty, "pty", "t", false, "Run the command on a pseudo-terminal (TTY) so terminal-aware tools format correctly; the output is rendered by a virtual terminal")
+	rootCmd.PersistentFlags().BoolVarP(&rootFlags.wrap, "wrap", "w", false, "Soft wrap long lines instead of scrolling horizontally")
+	rootCmd.PersistentFlags().UintVar(&rootFlags.freezeRows, "freeze-rows", 0, "Keep the first N lines visible while scrolling vertically")
+	rootCmd.PersistentFlags().UintVar(&rootFlags.freezeCols, "freeze-cols", 0, "Keep the first N columns visible while scrolling horizontally")
 	rootCmd.PersistentFlags().UintVarP(&rootFlags.interval, "interval", "n", 2, "Specify update interval")
+	rootCmd.PersistentFlags().BoolVar(&rootFlags.resizeRun, "rerun-on-resize", false, "Run the command again once the terminal is resized, so width-aware output fits the new size")
 	rootCmd.PersistentFlags().UintVarP(&rootFlags.records, "records", "r", 50, "Specify how many stdout records are kept in memory")
+	rootCmd.PersistentFlags().StringVar(&rootFlags.shell, "shell", "sh", "Shell running the command with -c; '$SHELL' runs it with your login shell")
+	rootCmd.PersistentFlags().StringVar(&rootFlags.stdin, "stdin", "", "Feed this file to the standard input of the command on every run")
+	rootCmd.PersistentFlags().BoolVarP(&rootFlags.stream, "stream", "S", false, "Show the output while the command is running instead of once it ends")
 	rootCmd.PersistentFlags().StringVarP(&rootFlags.title, "set-title", "T", "", "Replace the hostname in the status bar by a custom string")
 }
 
+// parseScrollMode maps the --anchor flag value to a viewport scroll mode.
+func parseScrollMode(s string) (viewport.ScrollMode, error) {
+	switch s {
+	case "":
+		return viewport.ScrollClamp, nil
+	case "line":
+		return viewport.ScrollAnchor, nil
+	case "tail":
+		return viewport.ScrollFollow, nil
+	default:
+		return 0, fmt.Errorf("invalid --anchor value %q: expected 'line' or 'tail'", s)
+	}
+}
+
+// parseSize parses a size in bytes with an optional K, M or G binary suffix.
+func parseSize(s string) (int, error) {
+	mult := 1
+	num := strings.TrimSuffix(strings.TrimSuffix(strings.ToUpper(s), "B"), "I")
+	if num != "" {
+		switch num[len(num)-1] {
+		case 'K':
+			mult = 1 << 10
+		case 'M':
+			mult = 1 << 20
+		case 'G':
+			mult = 1 << 30
+		}
+		if mult > 1 {
+			num = num[:len(num)-1]
+		}
+	}
+	n, err := strconv.Atoi(num)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("expected a size like 512K or 10M")
+	}
+	if n > math.MaxInt/mult {
+		return 0, fmt.Errorf("size too large")
+	}
+	return n * mult, nil
+}
+
+// checkReadable returns an error unless path is a file that can be read.
+func checkReadable(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if fi.IsDir() {
+		return fmt.Errorf("%s: is a directory", path)
+	}
+	return nil
+}
+
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		log.Fatal().Msgf("error: %v", err)
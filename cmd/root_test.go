@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseSize(t *testing.T) {
+	for s, want := range map[string]int{"0": 0, "4096": 4096, "512K": 512 << 10, "10M": 10 << 20, "1GiB": 1 << 30, "2mb": 2 << 20} {
+		got, err := parseSize(s)
+		if err != nil || got != want {
+			t.Fatalf("parseSize(%q): expected %d, got %d (%v)", s, want, got, err)
+		}
+	}
+	for _, s := range []string{"", "M", "ten", "-1K", "9223372036854775807K", "99999999999999999999"} {
+		if _, err := parseSize(s); err == nil {
+			t.Fatalf("parseSize(%q): expected an error", s)
+		}
+	}
+}
+
+func TestCheckReadable(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "input")
+	os.WriteFile(path, []byte("x"), 0o600)
+	if err := checkReadable(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, p := range []string{filepath.Join(dir, "missing"), dir} {
+		if err := checkReadable(p); err == nil {
+			t.Fatalf("expected an error for %q", p)
+		}
+	}
+}
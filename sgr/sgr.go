@@ -0,0 +1,197 @@
+// Package sgr tracks the graphic rendition of ANSI styled text, so a style
+// can be closed and re-opened anywhere in a line, and finds the escape
+// sequences within it.
+package sgr
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Attributes of the graphic rendition, one slot each in a State.
+const (
+	bold = iota
+	faint
+	italic
+	underline
+	blink
+	reverse
+	conceal
+	strike
+	overline
+	fg
+	bg
+	underlineColor
+	slots
+)
+
+// State is the graphic rendition in effect. It keeps one parameter per
+// attribute, so its size stays bounded however many sequences are applied.
+// The zero State is unstyled.
+type State [slots]string
+
+// Apply returns the state after applying seq. Sequences other than SGR
+// leave it untouched.
+func (s State) Apply(seq string) State {
+	params, ok := Params(seq)
+	if !ok {
+		return s
+	}
+	return s.ApplyParams(params)
+}
+
+// ApplyParams returns the state after applying the parameters of an SGR
+// sequence, as in "1;31" for "\x1b[1;31m".
+func (s State) ApplyParams(params string) State {
+	list := strings.Split(params, ";")
+	for i := 0; i < len(list); i++ {
+		p := list[i]
+		code, _, _ := strings.Cut(p, ":")
+		n := 0
+		if code != "" {
+			var err error
+			if n, err = strconv.Atoi(code); err != nil {
+				continue
+			}
+		}
+		switch {
+		case n == 0:
+			s = State{}
+		case n == 1:
+			s[bold] = p
+		case n == 2:
+			s[faint] = p
+		case n == 3:
+			s[italic] = p
+		case n == 4 && p == "4:0", n == 24:
+			s[underline] = ""
+		case n == 4, n == 21:
+			s[underline] = p
+		case n == 5, n == 6:
+			s[blink] = p
+		case n == 7:
+			s[reverse] = p
+		case n == 8:
+			s[conceal] = p
+		case n == 9:
+			s[strike] = p
+		case n == 22:
+			s[bold], s[faint] = "", ""
+		case n == 23:
+			s[italic] = ""
+		case n == 25:
+			s[blink] = ""
+		case n == 27:
+			s[reverse] = ""
+		case n == 28:
+			s[conceal] = ""
+		case n == 29:
+			s[strike] = ""
+		case n == 53:
+			s[overline] = p
+		case n == 55:
+			s[overline] = ""
+		case n >= 30 && n <= 37, n >= 90 && n <= 97:
+			s[fg] = p
+		case n == 39:
+			s[fg] = ""
+		case n >= 40 && n <= 47, n >= 100 && n <= 107:
+			s[bg] = p
+		case n == 49:
+			s[bg] = ""
+		case n == 59:
+			s[underlineColor] = ""
+		case n == 38, n == 48, n == 58:
+			// Extended colors carry their arguments in the following
+			// parameters unless they use the colon form, as in 38:5:208.
+			if !strings.Contains(p, ":") {
+				args := colorArgs(list[i+1:])
+				p = strings.Join(list[i:i+1+args], ";")
+				i += args
+			}
+			switch n {
+			case 38:
+				s[fg] = p
+			case 48:
+				s[bg] = p
+			default:
+				s[underlineColor] = p
+			}
+		}
+	}
+	return s
+}
+
+// colorArgs returns how many of params are arguments of an extended color:
+// an index for 5, red, green and blue for 2.
+func colorArgs(params []string) int {
+	if len(params) == 0 {
+		return 0
+	}
+	switch params[0] {
+	case "5":
+		return min(2, len(params))
+	case "2":
+		return min(4, len(params))
+	default:
+		return 1
+	}
+}
+
+// String returns the SGR sequence setting the state on unstyled text, or ""
+// for the zero State.
+func (s State) String() string {
+	var params []string
+	for _, p := range s {
+		if p != "" {
+			params = append(params, p)
+		}
+	}
+	if len(params) == 0 {
+		return ""
+	}
+	return "\x1b[" + strings.Join(params, ";") + "m"
+}
+
+// SeqLen returns the byte length of the escape sequence at the start of s.
+func SeqLen(s string) int {
+	if len(s) < 2 {
+		return len(s)
+	}
+	switch s[1] {
+	case '[': // CSI: parameters and intermediates up to a final byte in 0x40–0x7e
+		for i := 2; i < len(s); i++ {
+			if s[i] >= 0x40 && s[i] <= 0x7e {
+				return i + 1
+			}
+		}
+		return len(s)
+	case ']', 'P', '_', '^', 'X': // string sequences terminated by BEL or ST
+		for i := 2; i < len(s); i++ {
+			if s[i] == ansi.BEL {
+				return i + 1
+			}
+			if s[i] == ansi.ESC && i+1 < len(s) && s[i+1] == '\\' {
+				return i + 2
+			}
+		}
+		return len(s)
+	default:
+		return 2
+	}
+}
+
+// Params returns the parameters of seq when it is an SGR sequence. Private
+// sequences ending in m, as in "\x1b[>4;1m", are not.
+func Params(seq string) (string, bool) {
+	if len(seq) < 3 || seq[0] != ansi.ESC || seq[1] != '[' || seq[len(seq)-1] != 'm' {
+		return "", false
+	}
+	params := seq[2 : len(seq)-1]
+	if params != "" && params[0] >= '<' && params[0] <= '?' {
+		return "", false
+	}
+	return params, true
+}
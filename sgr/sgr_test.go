@@ -0,0 +1,55 @@
+package sgr
+
+import "testing"
+
+func TestApply_CollapsesState(t *testing.T) {
+	tests := map[string]struct {
+		seqs []string
+		want string
+	}{
+		"accumulates":       {[]string{"\x1b[31m", "\x1b[1m"}, "\x1b[1;31m"},
+		"replaces color":    {[]string{"\x1b[31m", "\x1b[32m", "\x1b[34m"}, "\x1b[34m"},
+		"reset":             {[]string{"\x1b[1;31m", "\x1b[m"}, ""},
+		"reset then set":    {[]string{"\x1b[4m", "\x1b[0;32m"}, "\x1b[32m"},
+		"attribute off":     {[]string{"\x1b[1;3;31m", "\x1b[22;39m"}, "\x1b[3m"},
+		"extended colors":   {[]string{"\x1b[38;5;208;48;2;1;2;3;1m"}, "\x1b[1;38;5;208;48;2;1;2;3m"},
+		"colon colors":      {[]string{"\x1b[38:2::1:2:3m", "\x1b[4:3m"}, "\x1b[4:3;38:2::1:2:3m"},
+		"not sgr":           {[]string{"\x1b[31m", "\x1b[2K", "\x1b[>4;1m"}, "\x1b[31m"},
+		"curly underline 0": {[]string{"\x1b[4:3m", "\x1b[4:0m"}, ""},
+	}
+	for name, tt := range tests {
+		var s State
+		for _, seq := range tt.seqs {
+			s = s.Apply(seq)
+		}
+		if got := s.String(); got != tt.want {
+			t.Fatalf("%s: expected %q, got %q", name, tt.want, got)
+		}
+	}
+}
+
+func TestApply_Bounded(t *testing.T) {
+	var s State
+	for range 1000 {
+		s = s.Apply("\x1b[31m").Apply("\x1b[1m")
+	}
+	if got := s.String(); got != "\x1b[1;31m" {
+		t.Fatalf("expected the state to stay collapsed, got %q", got)
+	}
+}
+
+func TestSeqLen(t *testing.T) {
+	tests := map[string]int{
+		"\x1b[31mx":          5,
+		"\x1b]0;title\x07x":  10,
+		"\x1b]8;;url\x1b\\x": 10,
+		"\x1b7x":             2,
+		"\x1b[12":            4,
+		"\x1b":               1,
+	}
+	for s, want := range tests {
+		if got := SeqLen(s); got != want {
+			t.Fatalf("SeqLen(%q): expected %d, got %d", s, want, got)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package ui
+
+import (
+	"image/color"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/fabio42/sasqwatch/sgr"
+)
+
+// styledText is command output split into its visible runes and the SGR
+// sequence in effect for each of them, so diffs can run on the visible text
+// only and the original colors can be restored afterwards.
+type styledText struct {
+	runes  []rune
+	styles []string // SGR sequence in effect per rune, "" when unstyled
+}
+
+// parseStyled splits s into visible runes and their SGR state. SGR sequences
+// are tracked; any other escape sequence (cursor movement, OSC, ...) is dropped
+// since it cannot be rendered by the viewport anyway.
+func parseStyled(s string) styledText {
+	var st styledText
+	var state sgr.State
+	cur := ""
+	for i := 0; i < len(s); {
+		if s[i] != ansi.ESC {
+			r, size := utf8.DecodeRuneInString(s[i:])
+			st.runes = append(st.runes, r)
+			st.styles = append(st.styles, cur)
+			i += size
+			continue
+		}
+
+		n := sgr.SeqLen(s[i:])
+		if params, ok := sgr.Params(s[i : i+n]); ok {
+			state = state.ApplyParams(params)
+			cur = state.String()
+		}
+		i += n
+	}
+	return st
+}
+
+// text returns the visible text without any escape sequence.
+func (st styledText) text() string {
+	return string(st.runes)
+}
+
+// render rebuilds the styled string, layering bg[i] as background color on
+// top of the original style of rune i. bg may be nil, and nil entries leave
+// the rune untouched. Styles are reset at every line end so each line stands
+// on its own once the viewport splits the content.
+func (st styledText) render(bg []color.Color) string {
+	var out strings.Builder
+	var prevStyle string
+	var prevBg color.Color
+	open := false
+	for i, r := range st.runes {
+		if r == '\n' {
+			if open {
+				out.WriteString(ansi.ResetStyle)
+				open = false
+			}
+			out.WriteRune(r)
+			continue
+		}
+
+		style := st.styles[i]
+		var hl color.Color
+		if bg != nil {
+			hl = bg[i]
+		}
+		if !open || style != prevStyle || hl != prevBg {
+			if open {
+				out.WriteString(ansi.ResetStyle)
+			}
+			out.WriteString(style)
+			if hl != nil {
+				out.WriteString(ansi.NewStyle().BackgroundColor(hl).String())
+			}
+			open = style != "" || hl != nil
+			prevStyle, prevBg = style, hl
+		}
+		out.WriteRune(r)
+	}
+	if open {
+		out.WriteString(ansi.ResetStyle)
+	}
+	return out.String()
+}
@@ -0,0 +1,59 @@
+package ui
+
+import (
+	"image/color"
+	"strings"
+	"testing"
+
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestParseStyled_SplitsVisibleTextAndStyles(t *testing.T) {
+	st := parseStyled("a\x1b[31mb\x1b[1mc\x1b[0md\x1b[2Ke")
+	if st.text() != "abcde" {
+		t.Fatalf("expected visible text 'abcde', got %q", st.text())
+	}
+	want := []string{"", "\x1b[31m", "\x1b[1;31m", "", ""}
+	for i := range want {
+		if st.styles[i] != want[i] {
+			t.Fatalf("rune %d: expected style %q, got %q", i, want[i], st.styles[i])
+		}
+	}
+}
+
+func TestStyledText_Render_RoundTripsVisibleText(t *testing.T) {
+	in := "\x1b[32mok\x1b[0m plain\n\x1b[31mfail\x1b[m"
+	out := parseStyled(in).render(nil)
+	if ansi.Strip(out) != ansi.Strip(in) {
+		t.Fatalf("expected visible text %q, got %q", ansi.Strip(in), ansi.Strip(out))
+	}
+	if !strings.Contains(out, "\x1b[32mok") || !strings.Contains(out, "\x1b[31mfail") {
+		t.Fatalf("expected original colors to be kept, got %q", out)
+	}
+}
+
+func TestStyledText_Render_LayersHighlight(t *testing.T) {
+	st := parseStyled("\x1b[32mab\x1b[0m")
+	hl := lipgloss.Color("1")
+	out := st.render([]color.Color{nil, hl})
+	want := "\x1b[32ma" + ansi.ResetStyle + "\x1b[32m" + ansi.NewStyle().BackgroundColor(hl).String() + "b" + ansi.ResetStyle
+	if out != want {
+		t.Fatalf("expected %q, got %q", want, out)
+	}
+}
+
+func TestRenderDiff_ColoredOutput_KeepsEscapesIntact(t *testing.T) {
+	m := newTestModel(5)
+	m.firstRun = false
+	m.diffOption = diffSimple
+	m.procCmdData(cmdDataWith("\x1b[32mup 10\x1b[0m", 0))
+	m.procCmdData(cmdDataWith("\x1b[32mup 12\x1b[0m", 0))
+
+	out := m.renderDiff()
+	hl := ansi.NewStyle().BackgroundColor(m.cfg.Theme.DiffColor).String()
+	want := "\x1b[32mup 1" + ansi.ResetStyle + "\x1b[32m" + hl + "2" + ansi.ResetStyle
+	if out != want {
+		t.Fatalf("expected %q, got %q", want, out)
+	}
+}
@@ -7,3 +7,9 @@ import "github.com/atotto/clipboard"
 func clipboardWriteAll(s string) error {
 	return clipboard.WriteAll(s)
 }
+
+// systemClipboardSupported reports whether a clipboard utility (xclip, xsel,
+// wl-copy, pbcopy...) was found.
+func systemClipboardSupported() bool {
+	return !clipboard.Unsupported
+}
@@ -0,0 +1,142 @@
+package ui
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/rs/zerolog/log"
+)
+
+// NewClipboard returns the Clipboard described by spec:
+//
+//	system        the system clipboard, falling back to osc52 when unavailable
+//	osc52         an OSC 52 sequence written by the program, for SSH sessions
+//	file:PATH     the copied text is written to PATH
+//	command:CMD   the copied text is piped to the shell command CMD
+//
+// An empty spec selects system.
+func NewClipboard(spec string) (Clipboard, error) {
+	kind, arg, _ := strings.Cut(spec, ":")
+	switch kind {
+	case "", "system":
+		osc52 := newOSC52Clipboard()
+		if !systemClipboardSupported() {
+			log.Debug().Str("function", "NewClipboard").Msg("no system clipboard, using osc52")
+			return osc52, nil
+		}
+		return fallbackClipboard{primary: atottoClipboard{}, fallback: osc52}, nil
+	case "osc52":
+		return newOSC52Clipboard(), nil
+	case "file":
+		if arg == "" {
+			return nil, fmt.Errorf("invalid clipboard %q: missing file path", spec)
+		}
+		return fileClipboard{path: arg}, nil
+	case "command":
+		if arg == "" {
+			return nil, fmt.Errorf("invalid clipboard %q: missing command", spec)
+		}
+		return commandClipboard{command: arg}, nil
+	default:
+		return nil, fmt.Errorf("invalid clipboard %q: expected system, osc52, file:PATH or command:CMD", spec)
+	}
+}
+
+// cmdClipboard is a Clipboard set through the program's output rather than
+// on its own: WriteCmd returns the command setting it, which Update returns
+// so the sequence does not interleave with the renderer's output.
+type cmdClipboard interface {
+	Clipboard
+	WriteCmd(s string) (tea.Cmd, error)
+}
+
+// errCmdClipboard is returned by the Write method of the clipboards that can
+// only be set with WriteCmd.
+var errCmdClipboard = errors.New("clipboard is set through the program output")
+
+// osc52Clipboard asks the terminal to set the clipboard with an OSC 52
+// sequence. It works over SSH and on headless hosts, provided the terminal
+// supports it. Inside tmux the sequence is wrapped in a passthrough, which
+// needs tmux's allow-passthrough option.
+type osc52Clipboard struct {
+	tmux bool
+}
+
+func newOSC52Clipboard() osc52Clipboard {
+	return osc52Clipboard{tmux: os.Getenv("TMUX") != ""}
+}
+
+func (c osc52Clipboard) Write(string) error {
+	return errCmdClipboard
+}
+
+func (c osc52Clipboard) WriteCmd(s string) (tea.Cmd, error) {
+	if c.tmux {
+		return tea.Raw(ansi.TmuxPassthrough(ansi.SetSystemClipboard(s))), nil
+	}
+	return tea.SetClipboard(s), nil
+}
+
+// fileClipboard writes the copied text to a file, replacing its content.
+type fileClipboard struct {
+	path string
+}
+
+func (c fileClipboard) Write(s string) error {
+	return os.WriteFile(c.path, []byte(s), 0o600)
+}
+
+// commandClipboard pipes the copied text to a shell command such as
+// "wl-copy" or "tmux load-buffer -".
+type commandClipboard struct {
+	command string
+}
+
+func (c commandClipboard) Write(s string) error {
+	cmd := exec.Command("sh", "-c", c.command)
+	cmd.Stdin = strings.NewReader(s)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("clipboard command %q: %w: %s", c.command, err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+// fallbackClipboard writes to primary, and to fallback when primary fails,
+// e.g. when xclip is installed but no display is reachable.
+type fallbackClipboard struct {
+	primary, fallback Clipboard
+}
+
+func (c fallbackClipboard) Write(s string) error {
+	err := c.primary.Write(s)
+	if err == nil {
+		return nil
+	}
+	log.Debug().Str("function", "fallbackClipboard.Write").Msgf("primary clipboard failed, falling back: %v", err)
+	return c.fallback.Write(s)
+}
+
+func (c fallbackClipboard) WriteCmd(s string) (tea.Cmd, error) {
+	err := c.primary.Write(s)
+	if err == nil {
+		return nil, nil
+	}
+	log.Debug().Str("function", "fallbackClipboard.WriteCmd").Msgf("primary clipboard failed, falling back: %v", err)
+	return writeClipboard(c.fallback, s)
+}
+
+// writeClipboard copies s to clip, returning the command that sets it for the
+// clipboards set through the program's output.
+func writeClipboard(clip Clipboard, s string) (tea.Cmd, error) {
+	if c, ok := clip.(cmdClipboard); ok {
+		return c.WriteCmd(s)
+	}
+	return nil, clip.Write(s)
+}
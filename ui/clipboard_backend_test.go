@@ -0,0 +1,86 @@
+package ui
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+func TestOSC52Clipboard_Cmd(t *testing.T) {
+	cmd, err := writeClipboard(osc52Clipboard{}, "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cmd(); got != tea.SetClipboard("hi")() {
+		t.Fatalf("expected the program to set the clipboard, got %#v", got)
+	}
+
+	cmd, err = writeClipboard(osc52Clipboard{tmux: true}, "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cmd(); got != (tea.RawMsg{Msg: "\x1bPtmux;\x1b\x1b]52;c;aGk=\x07\x1b\\"}) {
+		t.Fatalf("unexpected tmux message %#v", got)
+	}
+
+	if err := (osc52Clipboard{}).Write("hi"); err == nil {
+		t.Fatalf("expected Write to fail outside of the program")
+	}
+}
+
+func TestNewClipboard_FileAndCommand(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clip")
+	for _, spec := range []string{"file:" + path, "command:cat > " + path} {
+		clip, err := NewClipboard(spec)
+		if err != nil {
+			t.Fatalf("%s: %v", spec, err)
+		}
+		if err := clip.Write(spec); err != nil {
+			t.Fatalf("%s: %v", spec, err)
+		}
+		if got, _ := os.ReadFile(path); string(got) != spec {
+			t.Fatalf("%s: expected the text in the file, got %q", spec, got)
+		}
+	}
+
+	clip, _ := NewClipboard("command:exit 3")
+	if err := clip.Write("x"); err == nil {
+		t.Fatalf("expected a failing command to return an error")
+	}
+}
+
+func TestNewClipboard_InvalidSpec(t *testing.T) {
+	for _, spec := range []string{"file:", "command:", "xclip"} {
+		if _, err := NewClipboard(spec); err == nil {
+			t.Fatalf("expected an error for %q", spec)
+		}
+	}
+}
+
+func TestFallbackClipboard(t *testing.T) {
+	primary := &fakeClipboard{err: errors.New("no display")}
+	fallback := &fakeClipboard{}
+	clip := fallbackClipboard{primary: primary, fallback: fallback}
+	if err := clip.Write("x"); err != nil || fallback.written != "x" {
+		t.Fatalf("expected the fallback to be used, got err=%v written=%q", err, fallback.written)
+	}
+}
+
+func TestFallbackClipboard_OSC52(t *testing.T) {
+	clip := fallbackClipboard{primary: &fakeClipboard{err: errors.New("no display")}, fallback: osc52Clipboard{}}
+	cmd, err := writeClipboard(clip, "hi")
+	if err != nil || cmd == nil {
+		t.Fatalf("expected the osc52 fallback to return a command, got %v", err)
+	}
+	if got := cmd(); got != tea.SetClipboard("hi")() {
+		t.Fatalf("expected the program to set the clipboard, got %#v", got)
+	}
+
+	clip.primary = &fakeClipboard{}
+	if cmd, err := writeClipboard(clip, "hi"); err != nil || cmd != nil {
+		t.Fatalf("expected the primary clipboard to be used, got cmd=%v err=%v", cmd != nil, err)
+	}
+}
@@ -0,0 +1,126 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	numberRe     = regexp.MustCompile(`-?\d+(?:\.\d+)?`)
+	whitespaceRe = regexp.MustCompile(`\s+`)
+)
+
+// annotateDeltas appends "(+delta, rate/s)" after every number of current that
+// changed compared to the same line of before. Lines are only compared when
+// their non-numeric skeleton matches, so numbers are never paired across
+// unrelated lines. Annotations eat into the padding that follows the number,
+// keeping at least one space, so columns shift as little as possible.
+// The returned slice flags the annotation runes for highlighting.
+func annotateDeltas(before string, current styledText, elapsed time.Duration) (styledText, []bool) {
+	var out styledText
+	var annotated []bool
+	appendRune := func(r rune, style string, mark bool) {
+		out.runes = append(out.runes, r)
+		out.styles = append(out.styles, style)
+		annotated = append(annotated, mark)
+	}
+
+	beforeLines := strings.Split(before, "\n")
+	offset := 0
+	for i, line := range strings.Split(current.text(), "\n") {
+		if i > 0 {
+			appendRune('\n', "", false)
+			offset++
+		}
+		notes := map[int]string{} // rune index (end of number) -> annotation
+		if i < len(beforeLines) && lineSkeleton(line) == lineSkeleton(beforeLines[i]) {
+			prev := numberRe.FindAllString(beforeLines[i], -1)
+			locs := numberRe.FindAllStringIndex(line, -1)
+			if len(prev) != len(locs) {
+				locs = nil
+			}
+			for k, loc := range locs {
+				if note := deltaNote(prev[k], line[loc[0]:loc[1]], elapsed); note != "" {
+					notes[utf8.RuneCountInString(line[:loc[1]])] = note
+				}
+			}
+		}
+
+		runes := []rune(line)
+		for j := 0; j <= len(runes); j++ {
+			if note, ok := notes[j]; ok {
+				for _, r := range note {
+					appendRune(r, "", true)
+				}
+				// Consume the padding the annotation now occupies.
+				spaces := 0
+				for j+spaces < len(runes) && runes[j+spaces] == ' ' {
+					spaces++
+				}
+				if j+spaces < len(runes) {
+					j += min(max(spaces-1, 0), utf8.RuneCountInString(note))
+				}
+			}
+			if j < len(runes) {
+				appendRune(runes[j], current.styles[offset+j], false)
+			}
+		}
+		offset += len(runes)
+	}
+	return out, annotated
+}
+
+// lineSkeleton returns line with numbers and whitespace runs normalized so
+// that lines differing only by their counters compare equal.
+func lineSkeleton(line string) string {
+	return whitespaceRe.ReplaceAllString(numberRe.ReplaceAllString(line, "#"), " ")
+}
+
+// deltaNote formats the change from prev to cur, or returns "" when the
+// value did not change.
+func deltaNote(prev, cur string, elapsed time.Duration) string {
+	a, errA := strconv.ParseFloat(prev, 64)
+	b, errB := strconv.ParseFloat(cur, 64)
+	if errA != nil || errB != nil || a == b {
+		return ""
+	}
+	decimals := max(decimalPlaces(prev), decimalPlaces(cur))
+	delta := strconv.FormatFloat(b-a, 'f', decimals, 64)
+	if b > a {
+		delta = "+" + delta
+	}
+	if elapsed <= 0 {
+		return fmt.Sprintf(" (%s)", delta)
+	}
+	return fmt.Sprintf(" (%s, %s/s)", delta, formatRate((b-a)/elapsed.Seconds()))
+}
+
+func decimalPlaces(num string) int {
+	if i := strings.IndexByte(num, '.'); i >= 0 {
+		return len(num) - i - 1
+	}
+	return 0
+}
+
+// formatRate keeps about three significant digits without switching to
+// scientific notation.
+func formatRate(r float64) string {
+	var s string
+	switch a := math.Abs(r); {
+	case a >= 100:
+		s = strconv.FormatFloat(r, 'f', 0, 64)
+	case a >= 10:
+		s = strconv.FormatFloat(r, 'f', 1, 64)
+	default:
+		s = strconv.FormatFloat(r, 'f', 2, 64)
+	}
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s
+}
@@ -0,0 +1,73 @@
+package ui
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAnnotateDeltas_ChangedNumber_UsesPadding(t *testing.T) {
+	before := "rx 12225                    tx 5"
+	current := parseStyled("rx 12345                    tx 5")
+
+	out, annotated := annotateDeltas(before, current, 2*time.Second)
+	want := "rx 12345 (+120, 60/s)       tx 5"
+	if out.text() != want {
+		t.Fatalf("expected %q, got %q", want, out.text())
+	}
+	marked := 0
+	for _, a := range annotated {
+		if a {
+			marked++
+		}
+	}
+	if marked != len(" (+120, 60/s)") {
+		t.Fatalf("expected only the annotation to be highlighted, got %d runes", marked)
+	}
+}
+
+func TestAnnotateDeltas_UnchangedOrUnrelatedLines_Untouched(t *testing.T) {
+	before := "used 10\nfree 4"
+	current := parseStyled("used 10\ncached 7")
+
+	out, _ := annotateDeltas(before, current, time.Second)
+	if out.text() != current.text() {
+		t.Fatalf("expected output untouched, got %q", out.text())
+	}
+}
+
+func TestDeltaNote(t *testing.T) {
+	cases := []struct {
+		prev, cur string
+		elapsed   time.Duration
+		want      string
+	}{
+		{"10", "7", time.Second, " (-3, -3/s)"},
+		{"1.5", "1.75", 0, " (+0.25)"},
+		{"100", "1100", 3 * time.Second, " (+1000, 333/s)"},
+		{"42", "42", time.Second, ""},
+	}
+	for _, tc := range cases {
+		if got := deltaNote(tc.prev, tc.cur, tc.elapsed); got != tc.want {
+			t.Errorf("deltaNote(%q, %q, %v) = %q, want %q", tc.prev, tc.cur, tc.elapsed, got, tc.want)
+		}
+	}
+}
+
+func TestDeltaHighlights_RateIgnoresIdenticalRuns(t *testing.T) {
+	m := newTestModel(5)
+	start := time.Now()
+	for i, d := range []cmdData{
+		{stdout: []byte("rx 100"), date: start},
+		{stdout: []byte("rx 100"), date: start.Add(5 * time.Second)},
+		{stdout: []byte("rx 120"), date: start.Add(10 * time.Second)},
+		{stdout: []byte("rx 120"), date: start.Add(50 * time.Second)},
+	} {
+		m.firstRun = i == 0
+		m.procCmdData(d)
+	}
+
+	out, _ := m.deltaHighlights()
+	if got, want := out.text(), "rx 120 (+20, 2/s)"; got != want {
+		t.Fatalf("expected the rate over the time between both values, got %q, want %q", got, want)
+	}
+}
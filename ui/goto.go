@@ -0,0 +1,51 @@
+package ui
+
+import (
+	"strconv"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+// maxCount bounds the numeric prefix so it cannot overflow.
+const maxCount = 1_000_000_000
+
+// updateCount handles the numeric prefix of g, G and %, like less and vim:
+// 42g or 42G go to line 42 and 50% to the middle of the output. It returns
+// false when msg is not part of a count; the pending count is then dropped.
+func (m *Model) updateCount(msg tea.KeyPressMsg) bool {
+	if len(msg.Text) == 1 && msg.Text[0] >= '0' && msg.Text[0] <= '9' {
+		m.count = min(m.count*10+int(msg.Text[0]-'0'), maxCount)
+		return true
+	}
+	n := m.count
+	m.count = 0
+	if n == 0 {
+		return false
+	}
+	switch msg.String() {
+	case "g", "G":
+		m.viewport.GotoLine(n - 1)
+	case "%":
+		m.viewport.GotoPercent(n)
+	default:
+		return false
+	}
+	return true
+}
+
+// gotoLine jumps to the location typed in the go-to-line prompt: a line
+// number, or a percentage of the output when followed by %. Anything else
+// is ignored.
+func (m *Model) gotoLine(spec string) {
+	spec = strings.TrimSpace(spec)
+	if pct, ok := strings.CutSuffix(spec, "%"); ok {
+		if n, err := strconv.Atoi(pct); err == nil {
+			m.viewport.GotoPercent(n)
+		}
+		return
+	}
+	if n, err := strconv.Atoi(spec); err == nil {
+		m.viewport.GotoLine(n - 1)
+	}
+}
@@ -0,0 +1,62 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+func newGotoModel() Model {
+	m := newTestModel(5)
+	m.viewport.Height = 5
+	lines := make([]string, 101)
+	for i := range lines {
+		lines[i] = fmt.Sprint(i + 1)
+	}
+	m.viewport.SetContent(strings.Join(lines, "\n"))
+	return m
+}
+
+func pressKeys(m Model, keys string) Model {
+	var model tea.Model = m
+	for _, r := range keys {
+		code := r
+		if r == '\n' {
+			code = tea.KeyEnter
+		}
+		model, _ = model.Update(tea.KeyPressMsg{Code: code, Text: strings.TrimSpace(string(r))})
+	}
+	return model.(Model)
+}
+
+func TestCount_LineAndPercent(t *testing.T) {
+	m := pressKeys(newGotoModel(), "42G")
+	if got := m.viewport.TopLine(); got != 41 {
+		t.Fatalf("expected 42G to show line 42 at the top, got %d", got+1)
+	}
+	m = pressKeys(m, "10%")
+	if got := m.viewport.TopLine(); got != 10 {
+		t.Fatalf("expected 10%% to show line 11 at the top, got %d", got+1)
+	}
+	m = pressKeys(m, "g")
+	if got := m.viewport.TopLine(); got != 0 || m.count != 0 {
+		t.Fatalf("expected g to go to the top, got line %d count %d", got+1, m.count)
+	}
+}
+
+func TestGotoPrompt(t *testing.T) {
+	m := pressKeys(newGotoModel(), ":30\n")
+	if got := m.viewport.TopLine(); got != 29 || m.promptKind != promptNone {
+		t.Fatalf("expected :30 to show line 30 at the top, got %d", got+1)
+	}
+	m = pressKeys(m, ":50%\n")
+	if got := m.viewport.TopLine(); got != 50 {
+		t.Fatalf("expected :50%% to show line 51 at the top, got %d", got+1)
+	}
+	m = pressKeys(m, ":abc\n")
+	if got := m.viewport.TopLine(); got != 50 {
+		t.Fatalf("expected an invalid line to be ignored, got %d", got+1)
+	}
+}
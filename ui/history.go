@@ -0,0 +1,38 @@
+package ui
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// historyRecord is a record of the history as exported by historyJSON.
+type historyRecord struct {
+	Time      time.Time `json:"time"`       // last run that produced the output
+	FirstSeen time.Time `json:"first_seen"` // first run that produced it
+	ExitCode  int       `json:"exit_code"`
+	Output    string    `json:"output"`
+	Truncated bool      `json:"truncated"`
+	CPUUser   float64   `json:"cpu_user_seconds"`
+	CPUSys    float64   `json:"cpu_sys_seconds"`
+	MaxRSS    int64     `json:"max_rss_bytes"` // 0 where it is not reported
+}
+
+// historyJSON returns the recorded outputs, oldest first, with the exit code
+// and the resource usage of their runs, as an indented JSON array.
+func (m *Model) historyJSON() string {
+	records := make([]historyRecord, 0, m.cmdRecords)
+	for _, d := range m.cmdsData[len(m.cmdsData)-m.cmdRecords:] {
+		records = append(records, historyRecord{
+			Time:      d.date,
+			FirstSeen: d.firstSeen(),
+			ExitCode:  d.exitCode,
+			Output:    string(d.stdout),
+			Truncated: d.truncated,
+			CPUUser:   d.usage.User.Seconds(),
+			CPUSys:    d.usage.Sys.Seconds(),
+			MaxRSS:    d.usage.MaxRSS,
+		})
+	}
+	out, _ := json.MarshalIndent(records, "", "  ")
+	return string(out) + "\n"
+}
@@ -0,0 +1,32 @@
+package ui
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+func TestCopyHistory_ExportsRecordsWithUsage(t *testing.T) {
+	m := newTestModel(5)
+	m.width, m.height = 80, 24
+	m.procCmdData(cmdDataWith("first", 0))
+	m.firstRun = false
+	m.procCmdData(cmdDataWith("second", 2))
+	m.cmdsData[len(m.cmdsData)-1].usage = ResourceUsage{User: 1500 * time.Millisecond, Sys: 250 * time.Millisecond, MaxRSS: 12 << 20}
+
+	model, _ := m.Update(tea.KeyPressMsg{Code: 'E', Text: "E"})
+	clip := model.(Model).cfg.Clip.(*fakeClipboard)
+
+	var records []historyRecord
+	if err := json.Unmarshal([]byte(clip.written), &records); err != nil {
+		t.Fatalf("expected the history as JSON, got %q: %v", clip.written, err)
+	}
+	if len(records) != 2 || records[0].Output != "first" || records[1].Output != "second" {
+		t.Fatalf("expected both records oldest first, got %+v", records)
+	}
+	if r := records[1]; r.ExitCode != 2 || r.CPUUser != 1.5 || r.CPUSys != 0.25 || r.MaxRSS != 12<<20 {
+		t.Fatalf("expected the exit code and usage of the last run, got %+v", r)
+	}
+}
@@ -1,6 +1,14 @@
 package ui
 
-import "charm.land/bubbles/v2/key"
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"charm.land/bubbles/v2/key"
+
+	"github.com/fabio42/sasqwatch/viewport"
+)
 
 const (
 	helpHeight     = 1
@@ -9,32 +17,162 @@ const (
 
 var (
 	km = keymap{
-		pause: key.NewBinding(key.WithKeys("space"), key.WithHelp("space", "pause/unpause")),
-		run:   key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "trigger command")),
-		prev:  key.NewBinding(key.WithKeys("[", "{"), key.WithHelp("[", "previous record")),
-		next:  key.NewBinding(key.WithKeys("]", "}"), key.WithHelp("]", "next record")),
-		diff:  key.NewBinding(key.WithKeys("d"), key.WithHelp("d", "change diff mode")),
-		incr:  key.NewBinding(key.WithKeys("+", "="), key.WithHelp("+", "increase interval")),
-		decr:  key.NewBinding(key.WithKeys("-", "_"), key.WithHelp("-", "decrease interval")),
-		quit:  key.NewBinding(key.WithKeys("ctrl+c", "q"), key.WithHelp("ctrl+c/q", "quit")),
-		copy:  key.NewBinding(key.WithKeys("y", "c"), key.WithHelp("y/c", "copy to clipboard")),
-		help:  key.NewBinding(key.WithKeys("?", "h"), key.WithHelp("?/h", "help")),
-		nav:   key.NewBinding(key.WithKeys(""), key.WithHelp("↑↓←→", "Pager navigation")),
+		pause:       key.NewBinding(key.WithKeys("space"), key.WithHelp("space", "pause/unpause")),
+		run:         key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "trigger command")),
+		prev:        key.NewBinding(key.WithKeys("[", "{"), key.WithHelp("[", "previous record")),
+		next:        key.NewBinding(key.WithKeys("]", "}"), key.WithHelp("]", "next record")),
+		diff:        key.NewBinding(key.WithKeys("d"), key.WithHelp("d", "change diff mode")),
+		nextChange:  key.NewBinding(key.WithKeys("."), key.WithHelp(".", "next change")),
+		prevChange:  key.NewBinding(key.WithKeys(","), key.WithHelp(",", "previous change")),
+		incr:        key.NewBinding(key.WithKeys("+", "="), key.WithHelp("+", "increase interval")),
+		decr:        key.NewBinding(key.WithKeys("-", "_"), key.WithHelp("-", "decrease interval")),
+		quit:        key.NewBinding(key.WithKeys("ctrl+c", "q"), key.WithHelp("ctrl+c/q", "quit")),
+		copy:        key.NewBinding(key.WithKeys("y", "c"), key.WithHelp("y/c", "copy to clipboard")),
+		copyView:    key.NewBinding(key.WithKeys("Y"), key.WithHelp("Y", "copy rendered view")),
+		copyPatch:   key.NewBinding(key.WithKeys("p"), key.WithHelp("p", "copy unified patch")),
+		copyHistory: key.NewBinding(key.WithKeys("E"), key.WithHelp("E", "copy history as JSON")),
+		visual:      key.NewBinding(key.WithKeys("v"), key.WithHelp("v", "visual line select")),
+		visualBlock: key.NewBinding(key.WithKeys("ctrl+v"), key.WithHelp("ctrl+v", "visual block select")),
+		help:        key.NewBinding(key.WithKeys("h"), key.WithHelp("h", "help")),
+		search:      key.NewBinding(key.WithKeys("/"), key.WithHelp("/", "search")),
+		searchBack:  key.NewBinding(key.WithKeys("?"), key.WithHelp("?", "search backward")),
+		nextMatch:   key.NewBinding(key.WithKeys("n"), key.WithHelp("n", "next match")),
+		prevMatch:   key.NewBinding(key.WithKeys("N"), key.WithHelp("N", "previous match")),
+		filter:      key.NewBinding(key.WithKeys("&"), key.WithHelp("&", "filter lines")),
+		filterNeg:   key.NewBinding(key.WithKeys("!"), key.WithHelp("!", "negate filter")),
+		wrap:        key.NewBinding(key.WithKeys("w"), key.WithHelp("w", "toggle line wrap")),
+		lineNumbers: key.NewBinding(key.WithKeys("#"), key.WithHelp("#", "toggle line numbers")),
+		scrollMode:  key.NewBinding(key.WithKeys("a"), key.WithHelp("a", "anchor/follow scroll")),
+		freezeRows:  key.NewBinding(key.WithKeys("z"), key.WithHelp("z", "freeze header rows")),
+		freezeCols:  key.NewBinding(key.WithKeys("Z"), key.WithHelp("Z", "freeze first column")),
+		gotoLine:    key.NewBinding(key.WithKeys(":"), key.WithHelp(":", "go to line or N%")),
+		jump:        key.NewBinding(key.WithKeys(""), key.WithHelp("g/G", "top/bottom, Ng line N")),
+		nav:         key.NewBinding(key.WithKeys(""), key.WithHelp("↑↓←→", "Pager navigation")),
+		nextPane:    key.NewBinding(key.WithKeys("tab"), key.WithHelp("tab", "next pane")),
+		prevPane:    key.NewBinding(key.WithKeys("shift+tab"), key.WithHelp("shift+tab", "previous pane")),
+		addTab:      key.NewBinding(key.WithKeys("t"), key.WithHelp("t", "watch another command")),
+		view:        viewport.DefaultKeyMap(),
 	}
 )
 
 type keymap struct {
-	pause key.Binding
-	run   key.Binding
-	prev  key.Binding
-	next  key.Binding
-	quit  key.Binding
-	diff  key.Binding
-	incr  key.Binding
-	decr  key.Binding
-	copy  key.Binding
-	help  key.Binding
-	nav   key.Binding
+	pause       key.Binding
+	run         key.Binding
+	prev        key.Binding
+	next        key.Binding
+	quit        key.Binding
+	diff        key.Binding
+	nextChange  key.Binding
+	prevChange  key.Binding
+	incr        key.Binding
+	decr        key.Binding
+	copy        key.Binding
+	copyView    key.Binding
+	copyPatch   key.Binding
+	copyHistory key.Binding
+	visual      key.Binding
+	visualBlock key.Binding
+	help        key.Binding
+	search      key.Binding
+	searchBack  key.Binding
+	nextMatch   key.Binding
+	prevMatch   key.Binding
+	filter      key.Binding
+	filterNeg   key.Binding
+	wrap        key.Binding
+	lineNumbers key.Binding
+	scrollMode  key.Binding
+	freezeRows  key.Binding
+	freezeCols  key.Binding
+	gotoLine    key.Binding
+	jump        key.Binding
+	nav         key.Binding
+	nextPane    key.Binding // handled by Multi
+	prevPane    key.Binding // handled by Multi
+	addTab      key.Binding // handled by Multi
+	view        viewport.KeyMap
+}
+
+// bindings returns the rebindable bindings of k by action name, as used in
+// Config.Keys.
+func (k *keymap) bindings() map[string]*key.Binding {
+	return map[string]*key.Binding{
+		"pause":        &k.pause,
+		"run":          &k.run,
+		"prev":         &k.prev,
+		"next":         &k.next,
+		"quit":         &k.quit,
+		"diff":         &k.diff,
+		"nextChange":   &k.nextChange,
+		"prevChange":   &k.prevChange,
+		"incr":         &k.incr,
+		"decr":         &k.decr,
+		"copy":         &k.copy,
+		"copyView":     &k.copyView,
+		"copyPatch":    &k.copyPatch,
+		"copyHistory":  &k.copyHistory,
+		"visual":       &k.visual,
+		"visualBlock":  &k.visualBlock,
+		"help":         &k.help,
+		"search":       &k.search,
+		"searchBack":   &k.searchBack,
+		"nextMatch":    &k.nextMatch,
+		"prevMatch":    &k.prevMatch,
+		"filter":       &k.filter,
+		"filterNeg":    &k.filterNeg,
+		"wrap":         &k.wrap,
+		"lineNumbers":  &k.lineNumbers,
+		"scrollMode":   &k.scrollMode,
+		"freezeRows":   &k.freezeRows,
+		"freezeCols":   &k.freezeCols,
+		"gotoLine":     &k.gotoLine,
+		"nextPane":     &k.nextPane,
+		"prevPane":     &k.prevPane,
+		"addTab":       &k.addTab,
+		"pageDown":     &k.view.PageDown,
+		"pageUp":       &k.view.PageUp,
+		"halfPageDown": &k.view.HalfPageDown,
+		"halfPageUp":   &k.view.HalfPageUp,
+		"down":         &k.view.Down,
+		"up":           &k.view.Up,
+		"left":         &k.view.Left,
+		"right":        &k.view.Right,
+		"top":          &k.view.Top,
+		"bottom":       &k.view.Bottom,
+	}
+}
+
+// withKeys returns k with the keys of the actions of keys replaced. Unknown
+// actions are ignored, see CheckKeys.
+func (k keymap) withKeys(keys map[string][]string) keymap {
+	bindings := k.bindings()
+	for action, ks := range keys {
+		if b, ok := bindings[action]; ok && len(ks) > 0 {
+			b.SetKeys(ks...)
+			b.SetHelp(strings.Join(ks, "/"), b.Help().Desc)
+		}
+	}
+	return k
+}
+
+// CheckKeys returns an error when keys rebinds an unknown action or leaves
+// an action without keys.
+func CheckKeys(keys map[string][]string) error {
+	bindings := km.bindings()
+	for action, ks := range keys {
+		if _, ok := bindings[action]; !ok {
+			actions := make([]string, 0, len(bindings))
+			for a := range bindings {
+				actions = append(actions, a)
+			}
+			slices.Sort(actions)
+			return fmt.Errorf("unknown key action %q, expected one of %s", action, strings.Join(actions, ", "))
+		}
+		if len(ks) == 0 {
+			return fmt.Errorf("no key for action %q", action)
+		}
+	}
+	return nil
 }
 
 func (m *Model) helpView() string {
@@ -55,12 +193,42 @@ func (m *Model) helpFullView() string {
 		},
 		{
 			m.keymap.diff,
+			m.keymap.nextChange,
+			m.keymap.prevChange,
+			m.keymap.filter,
+		},
+		{
 			m.keymap.incr,
 			m.keymap.decr,
 			m.keymap.copy,
 			m.keymap.nav,
 		},
 		{
+			m.keymap.search,
+			m.keymap.searchBack,
+			m.keymap.nextMatch,
+			m.keymap.prevMatch,
+		},
+		{
+			m.keymap.filterNeg,
+			m.keymap.wrap,
+			m.keymap.lineNumbers,
+			m.keymap.copyHistory,
+		},
+		{
+			m.keymap.scrollMode,
+			m.keymap.freezeRows,
+			m.keymap.freezeCols,
+		},
+		{
+			m.keymap.visual,
+			m.keymap.visualBlock,
+			m.keymap.copyView,
+			m.keymap.copyPatch,
+		},
+		{
+			m.keymap.jump,
+			m.keymap.gotoLine,
 			m.keymap.help,
 			m.keymap.quit,
 		},
@@ -0,0 +1,88 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+)
+
+// Parts of the output kept when it exceeds the limit, see --max-output-keep.
+const (
+	KeepHead = "head"
+	KeepTail = "tail"
+	KeepBoth = "both"
+)
+
+// limitWriter keeps at most head bytes from the start of what is written and
+// tail bytes from its end, so a runaway command cannot exhaust memory.
+type limitWriter struct {
+	head, tail int
+	headBuf    []byte
+	tailBuf    []byte // may hold up to twice tail bytes between trims
+	total      int64
+}
+
+// newLimitWriter returns a writer keeping limit bytes in total, from the
+// parts of the output selected by keep. A limit of 0 or less keeps all.
+func newLimitWriter(limit int, keep string) *limitWriter {
+	switch {
+	case limit <= 0:
+		return &limitWriter{head: math.MaxInt}
+	case keep == KeepHead:
+		return &limitWriter{head: limit}
+	case keep == KeepTail:
+		return &limitWriter{tail: limit}
+	default:
+		return &limitWriter{head: limit / 2, tail: limit - limit/2}
+	}
+}
+
+func (w *limitWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	w.total += int64(n)
+	if room := w.head - len(w.headBuf); room > 0 {
+		k := min(room, len(p))
+		w.headBuf = append(w.headBuf, p[:k]...)
+		p = p[k:]
+	}
+	if w.tail > 0 && len(p) > 0 {
+		w.tailBuf = append(w.tailBuf, p...)
+		if len(w.tailBuf) > 2*w.tail {
+			w.tailBuf = append([]byte(nil), w.tailBuf[len(w.tailBuf)-w.tail:]...)
+		}
+	}
+	return n, nil
+}
+
+// Bytes returns the kept output and whether some was dropped. When it was,
+// the kept parts are cut at line boundaries and joined by a marker line.
+func (w *limitWriter) Bytes() ([]byte, bool) {
+	head, tail := w.headBuf, w.tailBuf
+	if len(tail) > w.tail {
+		tail = tail[len(tail)-w.tail:]
+	}
+	if int64(len(head)+len(tail)) == w.total {
+		return append(append([]byte(nil), head...), tail...), false
+	}
+
+	if i := bytes.LastIndexByte(head, '\n'); i >= 0 {
+		head = head[:i+1]
+	}
+	if i := bytes.IndexByte(tail, '\n'); i >= 0 {
+		tail = tail[i+1:]
+	}
+	out := append([]byte(nil), head...)
+	if len(out) > 0 && out[len(out)-1] != '\n' {
+		out = append(out, '\n')
+	}
+	out = append(out, truncationMarker(formatBytes(w.total-int64(len(head)+len(tail))))...)
+	if len(tail) > 0 {
+		out = append(append(out, '\n'), tail...)
+	}
+	return out, true
+}
+
+// truncationMarker is the line replacing the dropped part of an output.
+func truncationMarker(dropped string) string {
+	return fmt.Sprintf("[… output truncated: %s dropped …]", dropped)
+}
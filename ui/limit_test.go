@@ -0,0 +1,48 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLimitWriter_Unlimited(t *testing.T) {
+	w := newLimitWriter(0, KeepBoth)
+	w.Write([]byte("abc\n"))
+	w.Write([]byte("def"))
+	if out, truncated := w.Bytes(); string(out) != "abc\ndef" || truncated {
+		t.Fatalf("expected everything kept, got %q %v", out, truncated)
+	}
+}
+
+func TestLimitWriter_KeepsHeadAndTailOnLines(t *testing.T) {
+	w := newLimitWriter(12, KeepBoth)
+	for _, line := range []string{"l1\n", "l2\n", "l3\n", "l4\n", "l5\n", "l6\n", "l7\n", "l8"} {
+		w.Write([]byte(line))
+	}
+	out, truncated := w.Bytes()
+	if !truncated {
+		t.Fatalf("expected the output to be truncated")
+	}
+	want := "l1\nl2\n" + truncationMarker("12B") + "\nl7\nl8"
+	if string(out) != want {
+		t.Fatalf("expected %q, got %q", want, out)
+	}
+}
+
+func TestLimitWriter_HeadAndTailOnly(t *testing.T) {
+	input := strings.Repeat("x\n", 100)
+
+	w := newLimitWriter(10, KeepHead)
+	w.Write([]byte(input))
+	if out, _ := w.Bytes(); !strings.HasPrefix(string(out), "x\nx\nx\nx\nx\n[") {
+		t.Fatalf("expected the head to be kept, got %q", out)
+	}
+
+	w = newLimitWriter(10, KeepTail)
+	for i := 0; i < len(input); i += 7 {
+		w.Write([]byte(input[i:min(i+7, len(input))]))
+	}
+	if out, _ := w.Bytes(); !strings.HasPrefix(string(out), "[") || !strings.HasSuffix(string(out), "]\nx\nx\nx\nx\n") {
+		t.Fatalf("expected the tail to be kept, got %q", out)
+	}
+}
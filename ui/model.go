@@ -1,23 +1,23 @@
 package ui
 
 import (
-	"bytes"
-	"errors"
-	"io"
-	"os/exec"
+	"image/color"
+	"regexp"
+	"strconv"
 	"strings"
-	"syscall"
 	"time"
+	"unicode/utf8"
 
 	"github.com/fabio42/sasqwatch/ui/theme"
 	"github.com/fabio42/sasqwatch/viewport"
 
 	"charm.land/bubbles/v2/help"
 	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/textinput"
 	"charm.land/bubbles/v2/timer"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
-	"github.com/creack/pty"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/rs/zerolog/log"
 	"github.com/sergi/go-diff/diffmatchpatch"
 )
@@ -26,64 +26,10 @@ const (
 	diffOff = iota
 	diffSimple
 	diffPerpetual
+	diffHeatmap
+	diffDelta
 )
 
-// CommandRunner abstracts shell execution so the model can be tested without spawning processes.
-type CommandRunner interface {
-	Run(command string, cols, rows int) (stdout []byte, exitCode int)
-}
-
-// shellRunner is the production implementation of CommandRunner.
-// When usePty is true it allocates a pseudo-terminal sized to cols×rows so that
-// terminal-aware programs (e.g. tools that draw width-adaptive tables) see a
-// real TTY and format their output correctly. Falls back to plain pipe-based
-// CombinedOutput if PTY allocation fails (e.g. CI / constrained environments).
-// When usePty is false (the default) the command runs on ordinary pipes.
-type shellRunner struct {
-	usePty bool
-}
-
-func (r shellRunner) Run(command string, cols, rows int) ([]byte, int) {
-	cmd := exec.Command("sh", "-c", command)
-
-	if !r.usePty {
-		out, err := cmd.CombinedOutput()
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			return out, exitErr.ExitCode()
-		}
-		return out, 0
-	}
-
-	ptmx, err := pty.StartWithSize(cmd, &pty.Winsize{
-		Rows: uint16(rows),
-		Cols: uint16(cols),
-	})
-	if err != nil {
-		// PTY unavailable — fall back to ordinary pipes.
-		log.Debug().Str("function", "shellRunner.Run").Msgf("pty start failed, falling back to pipes: %v", err)
-		out, runErr := cmd.CombinedOutput()
-		if exitErr, ok := runErr.(*exec.ExitError); ok {
-			return out, exitErr.ExitCode()
-		}
-		return out, 0
-	}
-	defer ptmx.Close()
-
-	var buf bytes.Buffer
-	_, copyErr := io.Copy(&buf, ptmx)
-	// On macOS (and some Linux kernels) the PTY master returns EIO once the
-	// child's slave side is closed — that is normal EOF, not a real error.
-	if copyErr != nil && !errors.Is(copyErr, syscall.EIO) {
-		log.Debug().Str("function", "shellRunner.Run").Msgf("pty read error: %v", copyErr)
-	}
-
-	waitErr := cmd.Wait()
-	if exitErr, ok := waitErr.(*exec.ExitError); ok {
-		return buf.Bytes(), exitErr.ExitCode()
-	}
-	return buf.Bytes(), 0
-}
-
 // Clipboard abstracts clipboard writes so the model can be tested without touching the system clipboard.
 type Clipboard interface {
 	Write(s string) error
@@ -99,18 +45,37 @@ func (atottoClipboard) Write(s string) error {
 
 // Config holds all configuration that cmd passes into the UI model.
 type Config struct {
-	Interval time.Duration
-	History  int
-	HostName string // pre-resolved; empty falls back to os.Hostname inside NewModel
-	Cmd      string
-	ChgExit  bool
-	Diff     bool
-	ErrExit  bool
-	PermDiff bool
-	Pty      bool // run the watched command on a pseudo-terminal (see --pty flag)
-	Theme    theme.SasqTheme
-	Runner   CommandRunner // optional; defaults to shellRunner{}
-	Clip     Clipboard     // optional; defaults to atottoClipboard{}
+	Interval   time.Duration
+	History    int
+	HostName   string   // pre-resolved; empty falls back to os.Hostname inside NewModel
+	Cmd        string   // command line, run by Shell and shown in the status bar
+	Shell      string   // shell running Cmd with -c; empty means sh
+	Exec       []string // when set, run directly without a shell (see --exec flag)
+	Env        []string // KEY=VALUE pairs added to the environment of the command
+	Dir        string   // working directory of the command
+	Stdin      string   // file fed to the standard input of the command
+	MaxOutput  int      // bytes of output kept per run; 0 keeps everything
+	KeepOutput string   // part of the output kept past MaxOutput: KeepHead, KeepTail or KeepBoth
+	Stream     bool     // show the output of a run while it is in progress
+	ChgExit    bool
+	Diff       bool
+	ErrExit    bool
+	PermDiff   bool
+	Heatmap    bool
+	Delta      bool
+	Pty        bool // run the watched command on a pseudo-terminal (see --pty flag)
+	Mouse      bool // enable mouse scrolling, status bar clicks and selection
+	ResizeRun  bool // rerun the command once the terminal stops being resized
+	Wrap       bool // soft wrap long lines instead of scrolling horizontally
+	LineNums   bool // show the line number gutter
+	Scroll     viewport.ScrollMode
+	FreezeRows int              // header lines kept visible while scrolling vertically
+	FreezeCols int              // columns kept visible while scrolling horizontally
+	Ignore     []*regexp.Regexp // changes of text matching these do not make a new record
+	Theme      theme.SasqTheme
+	Keys       map[string][]string // keys of the actions to rebind, see CheckKeys
+	Runner     CommandRunner       // optional; defaults to shellRunner{}
+	Clip       Clipboard           // optional; defaults to atottoClipboard{}
 }
 
 type cmdData struct {
@@ -118,9 +83,20 @@ type cmdData struct {
 	stdoutDiff string
 	exitCode   int
 	date       time.Time
+	changed    time.Time     // when this output was first seen; date is refreshed on every identical run
+	usage      ResourceUsage // of the last run that produced this output
+	truncated  bool          // part of the output was dropped, see Config.MaxOutput
 	header     string
 }
 
+// firstSeen returns when the output of d was first seen.
+func (d cmdData) firstSeen() time.Time {
+	if d.changed.IsZero() {
+		return d.date
+	}
+	return d.changed
+}
+
 type cmdQuery struct {
 	cmd    []string
 	result chan cmdData
@@ -133,35 +109,88 @@ type Model struct {
 	keymap      keymap
 	cmdsData    []cmdData
 	cfg         Config
-	execCh      chan cmdData
+	execCh      chan tea.Msg // cmdChunk while a run streams, then its cmdData
 	cmdPerpDiff string
+	cmdPerpBase string // text of the record the perpetual diff started from
 	cmdIdx      int
 	cmdRecords  int
 	diffOption  int
-	paused      bool
-	copyCb      bool
-	copyErr     bool // true when the last copy attempt failed
-	inProgress  bool // true while a command goroutine is running
-	forcedRun   bool
-	firstRun    bool
-	printHelp   bool
-	diffColors  int
-	width       int
-	height      int
+	diffStats   diffStats // insertions and deletions of the viewed record vs the previous one
+	hunks       []int     // first line of every changed hunk in the rendered diff
+	hunkIdx     int       // hunk last jumped to, -1 before any jump
+
+	prompt           textinput.Model
+	promptKind       int
+	promptOrigin     int            // viewport offset when the prompt was opened
+	promptPrevSearch *regexp.Regexp // search to restore when the prompt is cancelled
+	searchBack       bool           // last search was backward: n and N are swapped
+	promptPrevFilter *regexp.Regexp // filter to restore when the prompt is cancelled
+	promptPrevNeg    bool
+
+	filter     *regexp.Regexp // only lines matching filter are displayed
+	filterNeg  bool           // display the lines not matching filter instead
+	paused     bool
+	copyCb     bool
+	copyErr    bool // true when the last copy attempt failed
+	inProgress bool // true while a command goroutine is running
+	forcedRun  bool
+	firstRun   bool
+	printHelp  bool
+	selecting  bool         // a mouse drag selection is in progress
+	selStart   viewport.Pos // where the drag selection started
+
+	visual       bool // visual mode: the keys move a selection cursor
+	visualMode   viewport.SelectMode
+	visualAnchor viewport.Pos // where the selection started
+	visualCursor viewport.Pos
+
+	count      int // numeric prefix typed before g, G or %
+	resizeGen  int // incremented on every resize, see resizeRerun
+	runs       int // number of runs started, exported as SASQWATCH_RUN
+	lastExit   int // exit code of the last completed run
+	runStart   time.Time
+	partial    *partialOutput // output of the run in progress in streaming mode
+	unfocused  bool           // another pane has the focus, see Multi
+	changes    int            // number of times the output changed since the first run
+	diffColors int
+	width      int
+	height     int
 }
 
 type runCmd struct{}
 type updateStdOut struct{}
 type clipboardNotification struct{}
 
+// resizeRerun reruns the command after a resize, unless another resize
+// happened since: gen is compared with Model.resizeGen.
+type resizeRerun struct{ gen int }
+
+// resizeDebounce is how long the terminal size must stay the same before the
+// command is rerun.
+const resizeDebounce = 300 * time.Millisecond
+
 func runCmdEvent() tea.Msg       { return runCmd{} }
 func updateStdOutEvent() tea.Msg { return updateStdOut{} }
 
 func NewModel(cfg Config) Model {
 	vp := viewport.New(200, 10)
+	vp.MatchStyle = ansi.NewStyle().BackgroundColor(cfg.Theme.MatchColor).ForegroundColor(cfg.Theme.StatusModeFgColor)
+	vp.CurrentMatchStyle = ansi.NewStyle().BackgroundColor(cfg.Theme.CurrentMatchColor).ForegroundColor(cfg.Theme.StatusModeFgColor)
+	vp.GutterStyle = lipgloss.NewStyle().Foreground(cfg.Theme.GutterColor)
+	vp.SetWrap(cfg.Wrap)
+	vp.SetLineNumbers(cfg.LineNums)
+	vp.ScrollMode = cfg.Scroll
+	vp.SetFrozen(cfg.FreezeRows, cfg.FreezeCols)
 
 	if cfg.Runner == nil {
-		cfg.Runner = shellRunner{usePty: cfg.Pty}
+		cfg.Runner = shellRunner{
+			usePty:    cfg.Pty,
+			shell:     cfg.Shell,
+			argv:      cfg.Exec,
+			maxOutput: cfg.MaxOutput,
+			keep:      cfg.KeepOutput,
+			running:   &runningPty{},
+		}
 	}
 	if cfg.Clip == nil {
 		cfg.Clip = atottoClipboard{}
@@ -172,18 +201,26 @@ func NewModel(cfg Config) Model {
 		diffOpt = 1
 	} else if cfg.PermDiff {
 		diffOpt = 2
+	} else if cfg.Heatmap {
+		diffOpt = 3
+	} else if cfg.Delta {
+		diffOpt = 4
 	}
 
+	keys := km.withKeys(cfg.Keys)
+	vp.KeyMap = keys.view
+
 	return Model{
 		cfg:        cfg,
 		viewport:   &vp,
-		keymap:     km,
+		keymap:     keys,
 		paused:     false,
 		firstRun:   true,
 		cmdsData:   make([]cmdData, cfg.History),
-		execCh:     make(chan cmdData),
+		execCh:     make(chan tea.Msg),
 		diffColors: 1,
 		diffOption: diffOpt,
+		hunkIdx:    -1,
 		help:       help.New(),
 	}
 }
@@ -194,13 +231,13 @@ func (m Model) Init() tea.Cmd {
 
 // viewportHeight returns the correct viewport height given the current help state.
 func (m Model) viewportHeight() int {
+	// Panes of a Multi may be too small to hold anything but the status bar.
 	if m.printHelp {
-		return m.height - statusHeight - helpFullHeight
+		return max(0, m.height-statusHeight-helpFullHeight)
 	}
-	return m.height - statusHeight - helpHeight
+	return max(0, m.height-statusHeight-helpHeight)
 }
 
-
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	cmds := []tea.Cmd{}
 	switch msg := msg.(type) {
@@ -210,20 +247,40 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.viewport.Width = msg.Width
 		m.viewport.Height = m.viewportHeight()
 		cmds = append(cmds, updateStdOutEvent)
+		if r, ok := m.cfg.Runner.(Resizer); ok && m.inProgress {
+			r.Resize(m.ptySize())
+		}
+		if m.partial != nil {
+			m.partial.resize(m.ptySize())
+		}
+		if m.cfg.ResizeRun && !m.firstRun {
+			m.resizeGen++
+			gen := m.resizeGen
+			cmds = append(cmds, tea.Tick(resizeDebounce, func(_ time.Time) tea.Msg {
+				return resizeRerun{gen: gen}
+			}))
+		}
+
+	case resizeRerun:
+		if msg.gen == m.resizeGen && !m.paused {
+			cmds = append(cmds, runCmdEvent)
+		}
 
 	case tea.KeyPressMsg:
+		if m.promptKind != promptNone {
+			return m.updatePrompt(msg)
+		}
+		if m.visual {
+			return m.updateVisual(msg)
+		}
+		if m.updateCount(msg) {
+			return m, nil
+		}
 		switch {
 		case key.Matches(msg, m.keymap.quit):
 			return m, tea.Quit
 		case key.Matches(msg, m.keymap.pause):
-			if m.paused {
-				m.paused = false
-				m.cmdIdx = 0
-				cmds = append(cmds, runCmdEvent)
-			} else {
-				m.paused = true
-				cmds = append(cmds, m.timer.Stop())
-			}
+			cmds = append(cmds, m.togglePause())
 		case key.Matches(msg, m.keymap.run):
 			m.forcedRun = true
 			if m.paused {
@@ -231,27 +288,64 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return m, runCmdEvent
 		case key.Matches(msg, m.keymap.prev):
-			if !m.paused {
-				m.paused = true
-				cmds = append(cmds, m.timer.Stop())
-			}
-			if m.cmdIdx < m.cmdRecords-1 {
-				m.cmdIdx++
-				log.Debug().Str("function", "Update").Str("case", "prev").
-					Msgf("cmdIdx: %v - cmdRecords: %v", m.cmdIdx, m.cmdRecords)
-				cmds = append(cmds, updateStdOutEvent)
-			}
+			cmds = append(cmds, m.prevRecord()...)
 		case key.Matches(msg, m.keymap.next):
-			if m.cmdIdx > 0 {
-				m.cmdIdx--
+			cmds = append(cmds, m.nextRecord())
+		case key.Matches(msg, m.keymap.diff):
+			cmds = append(cmds, m.cycleDiff())
+		case key.Matches(msg, m.keymap.search):
+			return m, m.openPrompt(promptSearch, "/")
+		case key.Matches(msg, m.keymap.searchBack):
+			return m, m.openPrompt(promptSearchBack, "?")
+		case key.Matches(msg, m.keymap.filter):
+			return m, m.openPrompt(promptFilter, "&")
+		case key.Matches(msg, m.keymap.gotoLine):
+			return m, m.openPrompt(promptGoto, ":")
+		case key.Matches(msg, m.keymap.filterNeg):
+			if m.filter != nil {
+				m.setFilter(m.filter, !m.filterNeg)
 				cmds = append(cmds, updateStdOutEvent)
 			}
-		case key.Matches(msg, m.keymap.diff):
-			if m.diffOption >= diffPerpetual {
-				m.diffOption = diffOff
+		case key.Matches(msg, m.keymap.wrap):
+			m.viewport.SetWrap(!m.viewport.Wrap())
+			// The viewport would otherwise also handle the key.
+			return m, nil
+		case key.Matches(msg, m.keymap.lineNumbers):
+			m.viewport.SetLineNumbers(!m.viewport.LineNumbers())
+		case key.Matches(msg, m.keymap.scrollMode):
+			m.viewport.ScrollMode = (m.viewport.ScrollMode + 1) % (viewport.ScrollFollow + 1)
+		case key.Matches(msg, m.keymap.freezeRows):
+			rows := 0
+			if m.viewport.FrozenRows() == 0 {
+				rows = max(m.cfg.FreezeRows, 1)
+			}
+			m.viewport.SetFrozen(rows, m.viewport.FrozenCols())
+		case key.Matches(msg, m.keymap.freezeCols):
+			cols := 0
+			if m.viewport.FrozenCols() == 0 {
+				cols = m.cfg.FreezeCols
+				if cols == 0 {
+					header, _, _ := strings.Cut(m.output(len(m.cmdsData)-1-m.cmdIdx), "\n")
+					cols = firstColumnWidth(header)
+				}
+			}
+			m.viewport.SetFrozen(m.viewport.FrozenRows(), cols)
+		case key.Matches(msg, m.keymap.nextMatch):
+			if m.searchBack {
+				m.viewport.SearchPrev()
+			} else {
+				m.viewport.SearchNext()
+			}
+		case key.Matches(msg, m.keymap.prevMatch):
+			if m.searchBack {
+				m.viewport.SearchNext()
 			} else {
-				m.diffOption++
+				m.viewport.SearchPrev()
 			}
+		case key.Matches(msg, m.keymap.nextChange):
+			m.jumpHunk(true)
+		case key.Matches(msg, m.keymap.prevChange):
+			m.jumpHunk(false)
 		case key.Matches(msg, m.keymap.incr):
 			m.cfg.Interval = stepInterval(m.cfg.Interval, true)
 			if !m.paused {
@@ -265,17 +359,17 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				cmds = append(cmds, m.timer.Init())
 			}
 		case key.Matches(msg, m.keymap.copy):
-			err := m.cfg.Clip.Write(string(m.cmdsData[len(m.cmdsData)-1-m.cmdIdx].stdout))
-			if err != nil {
-				log.Debug().Str("function", "Update").Str("case", "copy").
-					Msgf("clipboard error: %v", err)
-				m.copyErr = true
-			} else {
-				m.copyCb = true
-			}
-			return m, tea.Tick(3*time.Second, func(_ time.Time) tea.Msg {
-				return clipboardNotification{}
-			})
+			return m, m.copyText(string(m.cmdsData[len(m.cmdsData)-1-m.cmdIdx].stdout))
+		case key.Matches(msg, m.keymap.copyView):
+			return m, m.copyText(ansi.Strip(m.viewport.Content()))
+		case key.Matches(msg, m.keymap.copyPatch):
+			return m, m.copyText(m.viewedPatch())
+		case key.Matches(msg, m.keymap.copyHistory):
+			return m, m.copyText(m.historyJSON())
+		case key.Matches(msg, m.keymap.visual):
+			m.openVisual(viewport.SelectLine)
+		case key.Matches(msg, m.keymap.visualBlock):
+			m.openVisual(viewport.SelectBlock)
 		case key.Matches(msg, m.keymap.help):
 			m.printHelp = !m.printHelp
 			m.viewport.Height = m.viewportHeight()
@@ -302,21 +396,36 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if !m.inProgress {
 			log.Debug().Str("function", "Update").Str("case", "runCmd").Msg("trigger command")
 			m.inProgress = true
-			cols, rows := m.width, m.viewportHeight()
-			if cols == 0 {
-				cols = 80
-			}
-			if rows <= 0 {
-				rows = 24
+			m.runs++
+			m.runStart = time.Now()
+			if m.cfg.Stream {
+				cmds = append(cmds, streamTickEvent(m.runs))
 			}
-			go execCmd(m.cfg.Cmd, cols, rows, m.execCh, m.cfg.Runner)
+			go execCmd(m.runRequest(), m.execCh, m.cfg.Runner, m.cfg.Stream)
 		} else {
 			log.Debug().Str("function", "Update").Str("case", "runCmd").Msg("command already in progress, skipping")
 		}
 		cmds = append(cmds, waitCmd(m.execCh))
 
+	case cmdChunk:
+		if m.partial == nil {
+			m.partial = m.newPartialOutput()
+		}
+		m.partial.Write(msg)
+		if m.streaming() {
+			cmds = append(cmds, updateStdOutEvent)
+		}
+		cmds = append(cmds, waitCmd(m.execCh))
+
+	case streamTick:
+		if m.inProgress && msg.run == m.runs {
+			cmds = append(cmds, streamTickEvent(msg.run))
+		}
+
 	case cmdData:
 		m.inProgress = false
+		m.partial = nil
+		m.lastExit = msg.exitCode
 		log.Debug().Str("function", "Update").Str("case", "cmdData").
 			Int("timerId", m.timer.ID()).Bool("paused", m.paused).Bool("timerRunning", m.timer.Running()).Msg("")
 
@@ -339,18 +448,39 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case updateStdOut:
 		log.Debug().Str("function", "Update").Str("case", "updateStdOut").Msg("event received")
-		if m.diffOption != diffOff {
+		if m.streaming() {
+			m.hunks, m.hunkIdx = nil, -1
+			m.viewport.SetContent(m.filterLines(m.partial.String()))
+		} else if m.diffOption != diffOff {
+			m.diffStats = m.viewedDiffStats()
 			m.viewport.SetContent(m.renderDiff())
 		} else {
-			m.viewport.SetContent(string(m.cmdsData[len(m.cmdsData)-1-m.cmdIdx].stdout))
+			m.hunks, m.hunkIdx = nil, -1
+			m.viewport.SetContent(m.output(len(m.cmdsData) - 1 - m.cmdIdx))
 		}
 
+	case tea.MouseClickMsg:
+		cmds = append(cmds, m.mouseClick(msg.Mouse())...)
+
+	case tea.MouseMotionMsg:
+		m.mouseMotion(msg.Mouse())
+
+	case tea.MouseReleaseMsg:
+		cmds = append(cmds, m.mouseRelease())
+
 	case clipboardNotification:
 		m.copyCb = false
 		m.copyErr = false
+		if !m.selecting && !m.visual {
+			m.viewport.ClearSelection()
+		}
 	}
 
 	var cmd tea.Cmd
+	if m.promptKind != promptNone {
+		m.prompt, cmd = m.prompt.Update(msg)
+		cmds = append(cmds, cmd)
+	}
 	*m.viewport, cmd = m.viewport.Update(msg)
 	cmds = append(cmds, cmd)
 	return m, tea.Batch(cmds...)
@@ -361,7 +491,9 @@ func (m Model) View() tea.View {
 	str.WriteString(m.statusView())
 	str.WriteString("\n\n")
 	str.WriteString(m.viewport.View())
-	if m.printHelp {
+	if m.promptKind != promptNone {
+		str.WriteString("\n" + m.prompt.View())
+	} else if m.printHelp {
 		str.WriteString(m.helpFullView())
 	} else {
 		str.WriteString(m.helpView())
@@ -369,9 +501,111 @@ func (m Model) View() tea.View {
 	v := tea.NewView(str.String())
 	v.AltScreen = true
 	v.MouseMode = tea.MouseModeNone
+	if m.cfg.Mouse {
+		v.MouseMode = tea.MouseModeCellMotion
+	}
 	return v
 }
 
+// togglePause pauses or resumes the command. Resuming goes back to the
+// latest record and runs the command immediately.
+func (m *Model) togglePause() tea.Cmd {
+	if m.paused {
+		m.paused = false
+		m.cmdIdx = 0
+		return runCmdEvent
+	}
+	m.paused = true
+	return m.timer.Stop()
+}
+
+// prevRecord pauses and shows the record preceding the viewed one.
+func (m *Model) prevRecord() []tea.Cmd {
+	var cmds []tea.Cmd
+	if !m.paused {
+		m.paused = true
+		cmds = append(cmds, m.timer.Stop())
+	}
+	if m.cmdIdx < m.cmdRecords-1 {
+		m.cmdIdx++
+		log.Debug().Str("function", "prevRecord").
+			Msgf("cmdIdx: %v - cmdRecords: %v", m.cmdIdx, m.cmdRecords)
+		cmds = append(cmds, updateStdOutEvent)
+	}
+	return cmds
+}
+
+// nextRecord shows the record following the viewed one.
+func (m *Model) nextRecord() tea.Cmd {
+	if m.cmdIdx > 0 {
+		m.cmdIdx--
+		return updateStdOutEvent
+	}
+	return nil
+}
+
+// cycleDiff switches to the next diff mode.
+func (m *Model) cycleDiff() tea.Cmd {
+	if m.diffOption >= diffDelta {
+		m.diffOption = diffOff
+	} else {
+		m.diffOption++
+	}
+	return updateStdOutEvent
+}
+
+// copyText writes s to the clipboard and shows the outcome in the status bar
+// for a few seconds.
+func (m *Model) copyText(s string) tea.Cmd {
+	set, err := writeClipboard(m.cfg.Clip, s)
+	if err != nil {
+		log.Debug().Str("function", "copyText").Msgf("clipboard error: %v", err)
+		m.copyErr = true
+	} else {
+		m.copyCb = true
+	}
+	return tea.Batch(set, tea.Tick(3*time.Second, func(_ time.Time) tea.Msg {
+		return clipboardNotification{}
+	}))
+}
+
+// runRequest describes the next run of the command. Besides Config.Env, the
+// command gets the run number, the exit code of the previous run and the size
+// of its terminal, so scripts can adapt to being watched.
+func (m Model) runRequest() RunRequest {
+	cols, rows := m.ptySize()
+	env := append([]string(nil), m.cfg.Env...)
+	env = append(env,
+		"SASQWATCH_RUN="+strconv.Itoa(m.runs),
+		"SASQWATCH_COLUMNS="+strconv.Itoa(cols),
+		"SASQWATCH_LINES="+strconv.Itoa(rows),
+	)
+	if m.runs > 1 {
+		env = append(env, "SASQWATCH_PREV_EXIT="+strconv.Itoa(m.lastExit))
+	}
+	return RunRequest{
+		Command: m.cfg.Cmd,
+		Cols:    cols,
+		Rows:    rows,
+		Env:     env,
+		Dir:     m.cfg.Dir,
+		Stdin:   m.cfg.Stdin,
+	}
+}
+
+// ptySize returns the size of the terminal the command runs on: the size of
+// the viewport, or 80x24 until the window size is known.
+func (m Model) ptySize() (cols, rows int) {
+	cols, rows = m.width, m.viewportHeight()
+	if cols == 0 {
+		cols = 80
+	}
+	if rows <= 0 {
+		rows = 24
+	}
+	return cols, rows
+}
+
 // stepInterval returns d adjusted up or down by a magnitude-scaled step.
 // The step grows with the interval so adjustments feel natural at any scale:
 //
@@ -411,6 +645,40 @@ func stepInterval(d time.Duration, up bool) time.Duration {
 	return time.Duration(secs) * time.Second
 }
 
+// output returns the stdout of record i with the line filter applied.
+func (m *Model) output(i int) string {
+	return m.filterLines(string(m.cmdsData[i].stdout))
+}
+
+// filterLines returns the lines of stdout kept by the line filter.
+func (m *Model) filterLines(stdout string) string {
+	if m.filter == nil {
+		return stdout
+	}
+	lines := strings.Split(stdout, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if m.filter.MatchString(ansi.Strip(line)) != m.filterNeg {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+var firstColumnRe = regexp.MustCompile(`^\s*\S+\s+`)
+
+// firstColumnWidth returns the width of the first column of a table header
+// line, including the padding up to the second column, or 0 when the line
+// has a single field.
+func firstColumnWidth(header string) int {
+	text := strings.TrimRight(ansi.Strip(header), " ")
+	loc := firstColumnRe.FindStringIndex(text)
+	if loc == nil || loc[1] == len(text) {
+		return 0
+	}
+	return ansi.StringWidth(text[:loc[1]])
+}
+
 // procCmdData updates the in-memory command history ring buffer.
 // Returns a non-nil tea.Cmd only when a forced exit condition is met.
 func (m *Model) procCmdData(d cmdData) tea.Cmd {
@@ -419,11 +687,15 @@ func (m *Model) procCmdData(d cmdData) tea.Cmd {
 		return tea.Quit
 	}
 
-	if string(d.stdout) != string(m.cmdsData[len(m.cmdsData)-1].stdout) {
+	if !m.sameOutput(d.stdout, m.cmdsData[len(m.cmdsData)-1].stdout) {
 		if m.cfg.ChgExit && !m.firstRun {
 			log.Debug().Str("function", "procCmdData").Msg("chgExit: output changed, quitting")
 			return tea.Quit
 		}
+		d.changed = d.date
+		if !m.firstRun {
+			m.changes++
+		}
 		b := make([]cmdData, cap(m.cmdsData))
 		copy(b, m.cmdsData[1:])
 		b[len(b)-1] = d
@@ -432,12 +704,35 @@ func (m *Model) procCmdData(d cmdData) tea.Cmd {
 			m.cmdRecords++
 		}
 	} else {
-		// Output unchanged; only refresh the timestamp.
+		// Output unchanged, or only in ignored parts; refresh the record
+		// without making a new one.
+		m.cmdsData[len(m.cmdsData)-1].stdout = d.stdout
+		m.cmdsData[len(m.cmdsData)-1].truncated = d.truncated
 		m.cmdsData[len(m.cmdsData)-1].date = d.date
+		m.cmdsData[len(m.cmdsData)-1].usage = d.usage
 	}
 	return nil
 }
 
+// sameOutput returns whether outputs a and b only differ by text matching
+// the ignore patterns.
+func (m *Model) sameOutput(a, b []byte) bool {
+	if len(m.cfg.Ignore) == 0 {
+		return string(a) == string(b)
+	}
+	return m.withoutIgnored(a) == m.withoutIgnored(b)
+}
+
+// withoutIgnored returns out with the text matching the ignore patterns
+// removed.
+func (m *Model) withoutIgnored(out []byte) string {
+	s := string(out)
+	for _, re := range m.cfg.Ignore {
+		s = re.ReplaceAllString(s, "")
+	}
+	return s
+}
+
 // diffSegment represents a single equal or inserted span from a diff.
 type diffSegment struct {
 	text     string
@@ -495,49 +790,289 @@ func (m *Model) renderDiff() string {
 		Msg("diff processing")
 
 	if m.diffOption > diffOff && m.cmdPerpDiff == "" {
-		m.cmdPerpDiff = string(m.cmdsData[len(m.cmdsData)-1].stdout)
+		m.cmdPerpDiff = parseStyled(m.output(len(m.cmdsData) - 1)).text()
+		m.cmdPerpBase = m.cmdPerpDiff
 	}
 	if m.cmdRecords == 0 || (m.cmdRecords == m.cfg.History && m.cmdIdx == m.cfg.History-1) {
-		return string(m.cmdsData[len(m.cmdsData)-1].stdout)
+		m.hunks = nil
+		return m.output(len(m.cmdsData) - 1)
 	}
 
-	current := string(m.cmdsData[len(m.cmdsData)-1-m.cmdIdx].stdout)
-	before := string(m.cmdsData[len(m.cmdsData)-2-m.cmdIdx].stdout)
+	var current styledText
+	var bg []color.Color
+	switch m.diffOption {
+	case diffHeatmap:
+		current, bg = m.heatmapHighlights()
+	case diffDelta:
+		current, bg = m.deltaHighlights()
+	default:
+		current, bg = m.diffHighlights()
+	}
+
+	m.hunks = hunkLines(current.runes, bg)
+	if m.hunkIdx >= len(m.hunks) {
+		m.hunkIdx = -1
+	}
+	return current.render(bg)
+}
+
+// diffHighlights diffs the visible text only so escape sequences are never
+// split; the highlight is then layered on top of the original colors.
+func (m *Model) diffHighlights() (styledText, []color.Color) {
+	current := parseStyled(m.output(len(m.cmdsData) - 1 - m.cmdIdx))
+	before := parseStyled(m.output(len(m.cmdsData) - 2 - m.cmdIdx))
 
-	segments, newPerpBase := computeDiff(before, current, m.cmdPerpDiff, m.diffOption == diffPerpetual)
+	segments, newPerpBase := computeDiff(before.text(), current.text(), m.cmdPerpDiff, m.diffOption == diffPerpetual)
 	if m.diffOption == diffPerpetual {
 		m.cmdPerpDiff = newPerpBase
 	}
 
-	insertStyle := lipgloss.NewStyle().Background(m.cfg.Theme.DiffColor)
-	var out strings.Builder
+	bg := make([]color.Color, len(current.runes))
+	i := 0
 	for _, seg := range segments {
+		n := utf8.RuneCountInString(seg.text)
 		if seg.inserted {
-			out.WriteString(insertStyle.Render(seg.text))
-		} else {
-			out.WriteString(seg.text)
+			for k := i; k < i+n; k++ {
+				bg[k] = m.cfg.Theme.DiffColor
+			}
+		}
+		i += n
+	}
+	return current, bg
+}
+
+// hunkLines returns the first line of every block of consecutive lines
+// holding at least one highlighted rune.
+func hunkLines(runes []rune, bg []color.Color) []int {
+	var hunks []int
+	line, lastHit := 0, -2
+	for i, r := range runes {
+		if r == '\n' {
+			line++
+			continue
+		}
+		if bg[i] == nil || lastHit == line {
+			continue
+		}
+		if lastHit != line-1 {
+			hunks = append(hunks, line)
+		}
+		lastHit = line
+	}
+	return hunks
+}
+
+// diffStats counts what changed between two records.
+type diffStats struct {
+	insLines, delLines int
+	insChars, delChars int
+}
+
+// computeDiffStats returns the number of inserted and deleted lines and
+// characters needed to turn before into current.
+func computeDiffStats(before, current string) diffStats {
+	var st diffStats
+	dmp := diffmatchpatch.New()
+
+	for _, d := range dmp.DiffMain(before, current, false) {
+		switch d.Type {
+		case diffmatchpatch.DiffInsert:
+			st.insChars += utf8.RuneCountInString(d.Text)
+		case diffmatchpatch.DiffDelete:
+			st.delChars += utf8.RuneCountInString(d.Text)
 		}
 	}
-	return out.String()
+
+	a, b, lines := dmp.DiffLinesToChars(before, current)
+	for _, d := range dmp.DiffCharsToLines(dmp.DiffMain(a, b, false), lines) {
+		n := strings.Count(d.Text, "\n")
+		if !strings.HasSuffix(d.Text, "\n") {
+			n++
+		}
+		switch d.Type {
+		case diffmatchpatch.DiffInsert:
+			st.insLines += n
+		case diffmatchpatch.DiffDelete:
+			st.delLines += n
+		}
+	}
+	return st
+}
+
+// viewedDiffStats compares the viewed record with the record its diff is
+// highlighted against: the record the perpetual diff started from in
+// permDiff mode, the record preceding it otherwise. The oldest record has
+// nothing to compare against and reports no change.
+func (m *Model) viewedDiffStats() diffStats {
+	current := parseStyled(m.output(len(m.cmdsData) - 1 - m.cmdIdx)).text()
+	if m.diffOption == diffPerpetual {
+		base := m.cmdPerpBase
+		if m.cmdPerpDiff == "" {
+			// Not rendered yet: renderDiff starts from the latest record.
+			base = parseStyled(m.output(len(m.cmdsData) - 1)).text()
+		}
+		return computeDiffStats(base, current)
+	}
+	if m.cmdIdx >= m.cmdRecords-1 {
+		return diffStats{}
+	}
+	before := parseStyled(m.output(len(m.cmdsData) - 2 - m.cmdIdx)).text()
+	return computeDiffStats(before, current)
+}
+
+// computeHeat returns, for every rune of records[0], the age of its most recent
+// change: 0 when it was inserted by records[0] itself, 1 when inserted by
+// records[1], and so on. Runes that never changed across records get -1.
+// records are ordered newest first.
+func computeHeat(records []string) []int {
+	if len(records) == 0 {
+		return nil
+	}
+	heat := make([]int, utf8.RuneCountInString(records[0]))
+	// pos tracks where each rune of records[0] lives in the record being compared.
+	pos := make([]int, len(heat))
+	for i := range heat {
+		heat[i] = -1
+		pos[i] = i
+	}
+
+	dmp := diffmatchpatch.New()
+	for age := 0; age < len(records)-1; age++ {
+		newer, older := records[age], records[age+1]
+		toOlder := make([]int, utf8.RuneCountInString(newer))
+		ni, oi := 0, 0
+		for _, d := range dmp.DiffMain(older, newer, false) {
+			n := utf8.RuneCountInString(d.Text)
+			switch d.Type {
+			case diffmatchpatch.DiffEqual:
+				for k := 0; k < n; k++ {
+					toOlder[ni] = oi
+					ni++
+					oi++
+				}
+			case diffmatchpatch.DiffInsert:
+				for k := 0; k < n; k++ {
+					toOlder[ni] = -1
+					ni++
+				}
+			case diffmatchpatch.DiffDelete:
+				oi += n
+			}
+		}
+
+		tracked := false
+		for i, p := range pos {
+			if p < 0 {
+				continue
+			}
+			if toOlder[p] < 0 {
+				heat[i] = age
+				pos[i] = -1
+				continue
+			}
+			pos[i] = toOlder[p]
+			tracked = true
+		}
+		if !tracked {
+			break
+		}
+	}
+	return heat
+}
+
+// heatmapHighlights colors the viewed record with a background picked from
+// the theme heat gradient according to how recently each region changed.
+func (m *Model) heatmapHighlights() (styledText, []color.Color) {
+	first := len(m.cmdsData) - 1 - m.cmdIdx
+	last := len(m.cmdsData) - m.cmdRecords
+	current := parseStyled(m.output(first))
+	records := make([]string, 0, first-last+1)
+	records = append(records, current.text())
+	for i := first - 1; i >= last; i-- {
+		records = append(records, parseStyled(m.output(i)).text())
+	}
+
+	bg := make([]color.Color, len(current.runes))
+	colors := m.cfg.Theme.HeatColors
+	depth := len(records) - 1
+	if len(colors) == 0 || depth < 1 {
+		return current, bg
+	}
+	for i, age := range computeHeat(records) {
+		if age >= 0 {
+			bg[i] = colors[age*len(colors)/depth]
+		}
+	}
+	return current, bg
+}
+
+// deltaHighlights annotates the numbers of the viewed record that changed
+// since the previous record with their delta and rate per second.
+func (m *Model) deltaHighlights() (styledText, []color.Color) {
+	cur := len(m.cmdsData) - 1 - m.cmdIdx
+	prev := cur - 1
+
+	// Identical runs refresh date, so the rate is computed between the
+	// times both values were first seen.
+	elapsed := m.cmdsData[cur].firstSeen().Sub(m.cmdsData[prev].firstSeen())
+	out, annotated := annotateDeltas(parseStyled(m.output(prev)).text(), parseStyled(m.output(cur)), elapsed)
+	bg := make([]color.Color, len(out.runes))
+	for i, a := range annotated {
+		if a {
+			bg[i] = m.cfg.Theme.DiffColor
+		}
+	}
+	return out, bg
+}
+
+// jumpHunk scrolls the viewport to the next (or previous) changed hunk of the
+// current diff, wrapping around at both ends.
+func (m *Model) jumpHunk(forward bool) {
+	if len(m.hunks) == 0 {
+		return
+	}
+	switch {
+	case m.hunkIdx < 0 && forward:
+		m.hunkIdx = 0
+	case m.hunkIdx < 0:
+		m.hunkIdx = len(m.hunks) - 1
+	case forward:
+		m.hunkIdx = (m.hunkIdx + 1) % len(m.hunks)
+	default:
+		m.hunkIdx = (m.hunkIdx - 1 + len(m.hunks)) % len(m.hunks)
+	}
+	m.viewport.GotoLine(m.hunks[m.hunkIdx])
 }
 
 // waitCmd bridges a cmdData channel result into the tea.Msg stream.
-func waitCmd(resp chan cmdData) tea.Cmd {
+func waitCmd(resp chan tea.Msg) tea.Cmd {
 	return func() tea.Msg {
-		return cmdData(<-resp)
+		return <-resp
 	}
 }
 
-// execCmd runs command via the provided CommandRunner and sends the result to outputChan.
+// execCmd runs req via the provided CommandRunner and sends the result to outputChan.
 // cols and rows reflect the current viewport dimensions so the child process can
 // format its output to the right width.
+// When stream is true the output read so far is sent as cmdChunk messages
+// before the result.
 // This function is meant to be called as a goroutine. It does not touch any model state.
-func execCmd(command string, cols, rows int, outputChan chan<- cmdData, runner CommandRunner) {
+func execCmd(req RunRequest, outputChan chan<- tea.Msg, runner CommandRunner, stream bool) {
 	log.Debug().Str("function", "execCmd").Msg("")
-	stdout, exitCode := runner.Run(command, cols, rows)
+	var batcher *chunkBatcher
+	if stream {
+		batcher = newChunkBatcher(outputChan)
+		req.Stream = batcher.add
+	}
+	res := runner.Run(req)
+	if batcher != nil {
+		batcher.stop()
+	}
 	outputChan <- cmdData{
-		stdout:   stdout,
-		exitCode: exitCode,
-		date:     time.Now(),
+		stdout:    res.Stdout,
+		exitCode:  res.ExitCode,
+		usage:     res.Usage,
+		truncated: res.Truncated,
+		date:      time.Now(),
 	}
 }
@@ -2,11 +2,16 @@ package ui
 
 import (
 	"errors"
+	"image/color"
+	"regexp"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/fabio42/sasqwatch/ui/theme"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
 )
 
 // --- fakes ---
@@ -29,10 +34,10 @@ func newFakeRunner(pairs ...struct {
 	return &fakeRunner{results: pairs}
 }
 
-func (f *fakeRunner) Run(_ string, _, _ int) ([]byte, int) {
+func (f *fakeRunner) Run(_ RunRequest) RunResult {
 	r := f.results[f.idx%len(f.results)]
 	f.idx++
-	return r.stdout, r.exitCode
+	return RunResult{Stdout: r.stdout, ExitCode: r.exitCode}
 }
 
 type fakeClipboard struct {
@@ -54,8 +59,11 @@ func newTestModel(history int) Model {
 		HostName: "testhost",
 		Cmd:      "echo hi",
 		Theme:    theme.DefaultTheme(),
-		Runner:   newFakeRunner(struct{ stdout []byte; exitCode int }{[]byte("hi"), 0}),
-		Clip:     &fakeClipboard{},
+		Runner: newFakeRunner(struct {
+			stdout   []byte
+			exitCode int
+		}{[]byte("hi"), 0}),
+		Clip: &fakeClipboard{},
 	}
 	return NewModel(cfg)
 }
@@ -222,6 +230,71 @@ func TestComputeDiff_Perpetual_NoChange_BaseUnchanged(t *testing.T) {
 	}
 }
 
+func TestComputeDiffStats(t *testing.T) {
+	st := computeDiffStats("a\nb\nc\n", "a\nB\nc\nd\n")
+	want := diffStats{insLines: 2, delLines: 1, insChars: 3, delChars: 1}
+	if st != want {
+		t.Fatalf("computeDiffStats = %+v, want %+v", st, want)
+	}
+}
+
+// --- change navigation tests ---
+
+func TestHunkLines_GroupsConsecutiveLines(t *testing.T) {
+	runes := []rune("a\nb\nc\nd\ne")
+	bg := make([]color.Color, len(runes))
+	hl := theme.DefaultTheme().DiffColor
+	bg[2], bg[4], bg[8] = hl, hl, hl // lines 1, 2 and 4
+
+	hunks := hunkLines(runes, bg)
+	if len(hunks) != 2 || hunks[0] != 1 || hunks[1] != 4 {
+		t.Fatalf("expected hunks [1 4], got %v", hunks)
+	}
+}
+
+func TestJumpHunk_WrapsAround(t *testing.T) {
+	m := newTestModel(5)
+	m.viewport.Height = 2
+	m.viewport.SetContent("0\n1\n2\n3\n4\n5\n6")
+	m.hunks = []int{1, 4}
+
+	m.jumpHunk(true)
+	m.jumpHunk(true)
+	if m.hunkIdx != 1 || m.viewport.YOffset != 4 {
+		t.Fatalf("expected hunk 1 at line 4, got hunk %d at line %d", m.hunkIdx, m.viewport.YOffset)
+	}
+	m.jumpHunk(true)
+	if m.hunkIdx != 0 || m.viewport.YOffset != 1 {
+		t.Fatalf("expected wrap to hunk 0 at line 1, got hunk %d at line %d", m.hunkIdx, m.viewport.YOffset)
+	}
+	m.jumpHunk(false)
+	if m.hunkIdx != 1 {
+		t.Fatalf("expected backward wrap to hunk 1, got %d", m.hunkIdx)
+	}
+}
+
+// --- computeHeat tests ---
+
+func TestComputeHeat_AgesByMostRecentChange(t *testing.T) {
+	// newest first: "c" changed in the latest record, "b" one record earlier.
+	heat := computeHeat([]string{"abc", "abx", "ayx"})
+	want := []int{-1, 1, 0}
+	for i := range want {
+		if heat[i] != want[i] {
+			t.Fatalf("computeHeat = %v, want %v", heat, want)
+		}
+	}
+}
+
+func TestComputeHeat_SingleRecord_NoHeat(t *testing.T) {
+	heat := computeHeat([]string{"abc"})
+	for _, h := range heat {
+		if h != -1 {
+			t.Fatalf("expected no heat without history, got %v", heat)
+		}
+	}
+}
+
 // --- stepInterval tests ---
 
 func TestStepInterval(t *testing.T) {
@@ -336,3 +409,227 @@ func TestCopy_Failure_SetsCopyErr(t *testing.T) {
 		t.Fatal("copyCb must NOT be set on clipboard write failure")
 	}
 }
+
+// --- search prompt tests ---
+
+func TestCompilePattern(t *testing.T) {
+	if compilePattern("") != nil {
+		t.Fatal("expected nil pattern for empty input")
+	}
+	if !compilePattern("pod").MatchString("POD-1") {
+		t.Fatal("lower case pattern should match case-insensitively")
+	}
+	if compilePattern("Pod").MatchString("pod-1") {
+		t.Fatal("pattern with upper case should match case-sensitively")
+	}
+	if !compilePattern("a(b").MatchString("xa(b") {
+		t.Fatal("invalid regexp should be matched literally")
+	}
+}
+
+func TestSearchPrompt_IncrementalAndPersistent(t *testing.T) {
+	m := newTestModel(5)
+	m.width, m.height = 80, 10
+	m.viewport.Height = m.viewportHeight()
+	m.viewport.SetContent("web-1\ndb-1\nweb-2")
+
+	model, _ := m.Update(tea.KeyPressMsg{Code: '/', Text: "/"})
+	m = model.(Model)
+	for _, r := range "web" {
+		model, _ = m.Update(tea.KeyPressMsg{Code: r, Text: string(r)})
+		m = model.(Model)
+	}
+	if m.viewport.MatchCount() != 2 {
+		t.Fatalf("expected 2 matches while typing, got %d", m.viewport.MatchCount())
+	}
+	model, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	m = model.(Model)
+	if m.promptKind != promptNone {
+		t.Fatal("expected prompt to close on enter")
+	}
+
+	model, _ = m.Update(tea.KeyPressMsg{Code: 'n', Text: "n"})
+	m = model.(Model)
+	if m.viewport.CurrentMatch() != 1 {
+		t.Fatalf("expected n to move to match 1, got %d", m.viewport.CurrentMatch())
+	}
+	if m.viewport.Search() == nil {
+		t.Fatal("expected search to persist after the prompt closes")
+	}
+}
+
+// --- filter tests ---
+
+func TestOutput_FilterAndNegation(t *testing.T) {
+	m := newTestModel(5)
+	m.procCmdData(cmdDataWith("web-1 Up\ndb-1 Up\n\x1b[31mweb-2\x1b[0m Exited", 0))
+	last := len(m.cmdsData) - 1
+
+	m.setFilter(compilePattern("web"), false)
+	if got := m.output(last); got != "web-1 Up\n\x1b[31mweb-2\x1b[0m Exited" {
+		t.Fatalf("unexpected filtered output %q", got)
+	}
+	m.setFilter(m.filter, true)
+	if got := m.output(last); got != "db-1 Up" {
+		t.Fatalf("unexpected negated output %q", got)
+	}
+}
+
+func TestFilterPrompt_EscRestoresPreviousFilter(t *testing.T) {
+	m := newTestModel(5)
+	m.width, m.height = 80, 10
+	m.setFilter(compilePattern("db"), false)
+
+	model, _ := m.Update(tea.KeyPressMsg{Code: '&', Text: "&"})
+	m = model.(Model)
+	for _, r := range "!web" {
+		model, _ = m.Update(tea.KeyPressMsg{Code: r, Text: string(r)})
+		m = model.(Model)
+	}
+	if m.filter.String() != "(?i)web" || !m.filterNeg {
+		t.Fatalf("expected live negated filter on 'web', got %v neg=%v", m.filter, m.filterNeg)
+	}
+	model, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEscape})
+	m = model.(Model)
+	if m.filter.String() != "(?i)db" || m.filterNeg {
+		t.Fatalf("expected filter 'db' to be restored, got %v neg=%v", m.filter, m.filterNeg)
+	}
+}
+
+func TestFirstColumnWidth(t *testing.T) {
+	cases := map[string]int{
+		"NAME        READY   STATUS":  12,
+		"  PID TTY          TIME CMD": 6,
+		"single":                      0,
+		"":                            0,
+	}
+	for header, want := range cases {
+		if got := firstColumnWidth(header); got != want {
+			t.Errorf("firstColumnWidth(%q) = %d, want %d", header, got, want)
+		}
+	}
+}
+
+// --- resize tests ---
+
+type resizingRunner struct {
+	fakeRunner
+	sizes [][2]int
+}
+
+func (r *resizingRunner) Resize(cols, rows int) {
+	r.sizes = append(r.sizes, [2]int{cols, rows})
+}
+
+func TestWindowSize_ResizesRunningCommand(t *testing.T) {
+	m := newTestModel(5)
+	runner := &resizingRunner{}
+	m.cfg.Runner = runner
+
+	model, _ := m.Update(tea.WindowSizeMsg{Width: 100, Height: 30})
+	if len(runner.sizes) != 0 {
+		t.Fatalf("expected no resize without a running command, got %v", runner.sizes)
+	}
+	m = model.(Model)
+	m.inProgress = true
+	m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	if want := [2]int{120, 40 - statusHeight - helpHeight}; len(runner.sizes) != 1 || runner.sizes[0] != want {
+		t.Fatalf("expected resize to %v, got %v", want, runner.sizes)
+	}
+}
+
+func TestWindowSize_DebouncedRerun(t *testing.T) {
+	m := newTestModel(5)
+	m.cfg.ResizeRun = true
+	m.firstRun = false
+
+	model, _ := m.Update(tea.WindowSizeMsg{Width: 100, Height: 30})
+	model, _ = model.Update(tea.WindowSizeMsg{Width: 110, Height: 30})
+	m = model.(Model)
+	if m.resizeGen != 2 {
+		t.Fatalf("expected a generation per resize, got %d", m.resizeGen)
+	}
+
+	_, cmd := m.Update(resizeRerun{gen: 1})
+	if containsMsg(cmd, runCmd{}) {
+		t.Fatalf("expected a stale resize not to rerun the command")
+	}
+	_, cmd = m.Update(resizeRerun{gen: 2})
+	if !containsMsg(cmd, runCmd{}) {
+		t.Fatalf("expected the last resize to rerun the command")
+	}
+}
+
+// containsMsg reports whether cmd, or one of the commands it batches,
+// produces msg. Ticks are not waited for.
+func containsMsg(cmd tea.Cmd, msg tea.Msg) bool {
+	if cmd == nil {
+		return false
+	}
+	done := make(chan tea.Msg, 1)
+	go func() { done <- cmd() }()
+	select {
+	case got := <-done:
+		if batch, ok := got.(tea.BatchMsg); ok {
+			for _, c := range batch {
+				if containsMsg(c, msg) {
+					return true
+				}
+			}
+			return false
+		}
+		return got == msg
+	case <-time.After(50 * time.Millisecond):
+		return false
+	}
+}
+
+func TestProcCmdData_IgnoredChangesRefreshTheRecord(t *testing.T) {
+	m := newTestModel(5)
+	m.cfg.Ignore = []*regexp.Regexp{regexp.MustCompile(`\d+s`)}
+	m.procCmdData(cmdDataWith("pod-a Running 10s", 0))
+	m.firstRun = false
+
+	m.procCmdData(cmdDataWith("pod-a Running 12s", 0))
+	if m.cmdRecords != 1 {
+		t.Fatalf("expected no new record for an ignored change, got %d records", m.cmdRecords)
+	}
+	if got := string(m.cmdsData[len(m.cmdsData)-1].stdout); got != "pod-a Running 12s" {
+		t.Fatalf("expected the record to show the latest output, got %q", got)
+	}
+
+	m.procCmdData(cmdDataWith("pod-a Failed 14s", 0))
+	if m.cmdRecords != 2 {
+		t.Fatalf("expected a new record for a real change, got %d records", m.cmdRecords)
+	}
+}
+
+func TestNewModel_Keys(t *testing.T) {
+	cfg := newTestModel(5).cfg
+	cfg.Keys = map[string][]string{"pause": {"P", "ctrl+p"}, "down": {"ctrl+n"}}
+	m := NewModel(cfg)
+	if !key.Matches(tea.KeyPressMsg{Code: 'n', Mod: tea.ModCtrl}, m.viewport.KeyMap.Down) {
+		t.Fatalf("expected the viewport to scroll down with ctrl+n")
+	}
+	if !key.Matches(tea.KeyPressMsg{Code: 'P', Text: "P"}, m.keymap.pause) || key.Matches(tea.KeyPressMsg{Code: tea.KeySpace, Text: " "}, m.keymap.pause) {
+		t.Fatalf("expected pause to be rebound to P")
+	}
+	if m.keymap.pause.Help().Key != "P/ctrl+p" {
+		t.Fatalf("expected the help to show the new keys, got %q", m.keymap.pause.Help().Key)
+	}
+	if !key.Matches(tea.KeyPressMsg{Code: tea.KeySpace, Text: " "}, newTestModel(5).keymap.pause) {
+		t.Fatalf("expected other models to keep the default keys")
+	}
+}
+
+func TestCheckKeys(t *testing.T) {
+	if err := CheckKeys(map[string][]string{"pause": {"P"}, "quit": {"Q"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := CheckKeys(map[string][]string{"explode": {"x"}}); err == nil {
+		t.Fatalf("expected an error for an unknown action")
+	}
+	if err := CheckKeys(map[string][]string{"pause": {}}); err == nil {
+		t.Fatalf("expected an error for an action without keys")
+	}
+}
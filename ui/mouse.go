@@ -0,0 +1,67 @@
+package ui
+
+import (
+	tea "charm.land/bubbletea/v2"
+	"github.com/fabio42/sasqwatch/viewport"
+)
+
+// mouseClick handles a button press. Clicks on the status bar act on the
+// segment under the pointer: the mode pauses or resumes, the record counter
+// shows the previous record on a left click and the next one on a right
+// click, and the diff mode cycles. A left press in the viewport starts a
+// drag selection, leaving visual mode.
+func (m *Model) mouseClick(mouse tea.Mouse) []tea.Cmd {
+	if mouse.Y == 0 {
+		switch m.statusZoneAt(mouse.X) {
+		case zoneMode:
+			return []tea.Cmd{m.togglePause()}
+		case zoneRecords:
+			if mouse.Button == tea.MouseRight {
+				return []tea.Cmd{m.nextRecord()}
+			}
+			return m.prevRecord()
+		case zoneDiff:
+			return []tea.Cmd{m.cycleDiff()}
+		}
+		return nil
+	}
+	if mouse.Button == tea.MouseLeft && m.inViewport(mouse.Y) {
+		m.closeVisual()
+		m.selecting = true
+		m.selStart = m.viewport.PosAt(mouse.X, mouse.Y-statusHeight)
+	}
+	return nil
+}
+
+// mouseMotion extends the drag selection to the pointer. Dragging past the
+// top or the bottom of the viewport scrolls it.
+func (m *Model) mouseMotion(mouse tea.Mouse) {
+	if !m.selecting || mouse.Button != tea.MouseLeft {
+		return
+	}
+	switch y := mouse.Y - statusHeight; {
+	case y < 0:
+		m.viewport.LineUp(1)
+	case y >= m.viewport.Height:
+		m.viewport.LineDown(1)
+	}
+	m.viewport.Select(m.selStart, m.viewport.PosAt(mouse.X, mouse.Y-statusHeight), viewport.SelectChar)
+}
+
+// mouseRelease ends the drag selection and copies the selected text. The
+// selection stays highlighted until the copy notification expires.
+func (m *Model) mouseRelease() tea.Cmd {
+	if !m.selecting {
+		return nil
+	}
+	m.selecting = false
+	if !m.viewport.HasSelection() {
+		return nil
+	}
+	return m.copyText(m.viewport.SelectedText())
+}
+
+// inViewport returns whether screen row y is displayed by the viewport.
+func (m *Model) inViewport(y int) bool {
+	return y >= statusHeight && y < statusHeight+m.viewport.Height
+}
@@ -0,0 +1,69 @@
+package ui
+
+import (
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+)
+
+func TestMouseClick_StatusZones(t *testing.T) {
+	m := newStatusModel(200)
+	m.cmdRecords = 3
+	m.diffOption = diffSimple
+
+	mode := m.statusZoneAt(0)
+	if mode != zoneMode {
+		t.Fatalf("expected mode zone at column 0, got %d", mode)
+	}
+	m.mouseClick(tea.Mouse{X: 0, Y: 0, Button: tea.MouseLeft})
+	if !m.paused {
+		t.Fatalf("expected a click on the mode to pause")
+	}
+
+	x := lipgloss.Width(m.statusSegments()[0]) // first column of the record counter
+	m.mouseClick(tea.Mouse{X: x, Y: 0, Button: tea.MouseLeft})
+	if m.cmdIdx != 1 {
+		t.Fatalf("expected a left click on the records to go back, got cmdIdx=%d", m.cmdIdx)
+	}
+	m.mouseClick(tea.Mouse{X: x, Y: 0, Button: tea.MouseRight})
+	if m.cmdIdx != 0 {
+		t.Fatalf("expected a right click on the records to go forward, got cmdIdx=%d", m.cmdIdx)
+	}
+
+	x += lipgloss.Width(m.statusSegments()[1])
+	m.mouseClick(tea.Mouse{X: x, Y: 0, Button: tea.MouseLeft})
+	if m.diffOption != diffPerpetual {
+		t.Fatalf("expected a click on the diff segment to cycle the mode, got %d", m.diffOption)
+	}
+}
+
+func TestMouseDrag_CopiesSelection(t *testing.T) {
+	m := newTestModel(5)
+	m.viewport.Height = 5
+	m.viewport.SetContent("alpha\nbravo\ncharlie")
+	clip := m.cfg.Clip.(*fakeClipboard)
+
+	m.mouseClick(tea.Mouse{X: 2, Y: statusHeight, Button: tea.MouseLeft})
+	m.mouseMotion(tea.Mouse{X: 3, Y: statusHeight + 1, Button: tea.MouseLeft})
+	if cmd := m.mouseRelease(); cmd == nil {
+		t.Fatalf("expected the notification tick after a copy")
+	}
+	if clip.written != "pha\nbrav" {
+		t.Fatalf("unexpected copied text %q", clip.written)
+	}
+	if !m.copyCb || m.selecting {
+		t.Fatalf("expected copy notification and the drag to be over")
+	}
+}
+
+func TestMouseClick_WithoutDragCopiesNothing(t *testing.T) {
+	m := newTestModel(5)
+	m.viewport.Height = 5
+	m.viewport.SetContent("alpha")
+
+	m.mouseClick(tea.Mouse{X: 2, Y: statusHeight, Button: tea.MouseLeft})
+	if cmd := m.mouseRelease(); cmd != nil || m.copyCb {
+		t.Fatalf("expected a plain click not to copy")
+	}
+}
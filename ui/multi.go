@@ -0,0 +1,328 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/textinput"
+	"charm.land/bubbles/v2/timer"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Layouts of the panes of a Multi.
+const (
+	LayoutRows    = "rows"    // panes stacked on top of each other
+	LayoutColumns = "columns" // panes side by side
+	LayoutTabs    = "tabs"    // a pane at a time, chosen in a tab bar
+)
+
+// paneMsg is a message produced by a command of a pane, routed back to it.
+type paneMsg struct {
+	pane int
+	msg  tea.Msg
+}
+
+// Multi watches several commands, each in its own pane with its own
+// interval, history, runner and diff state. Keys go to the focused pane,
+// tab and shift+tab move the focus and t adds a command.
+type Multi struct {
+	panes  []Model
+	seen   []int  // Model.changes of every pane when it was last displayed
+	base   Config // configuration of the commands added with t
+	focus  int
+	layout string
+	width  int
+	height int
+
+	adding bool // the prompt for a new command is open
+	prompt textinput.Model
+}
+
+// NewMulti returns a model watching a command per configuration, laid out
+// according to layout.
+func NewMulti(cfgs []Config, layout string) Multi {
+	m := Multi{layout: layout, base: cfgs[0]}
+	for _, cfg := range cfgs {
+		m.panes = append(m.panes, NewModel(cfg))
+		m.seen = append(m.seen, 0)
+	}
+	m.setFocus(0)
+	return m
+}
+
+func (m Multi) Init() tea.Cmd {
+	cmds := make([]tea.Cmd, len(m.panes))
+	for i, p := range m.panes {
+		cmds[i] = tagCmd(i, p.Init())
+	}
+	return tea.Batch(cmds...)
+}
+
+func (m Multi) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	m, cmd := m.update(msg)
+	for i := range m.panes {
+		if m.visible(i) {
+			m.seen[i] = m.panes[i].changes
+		}
+	}
+	return m, cmd
+}
+
+func (m Multi) update(msg tea.Msg) (Multi, tea.Cmd) {
+	switch msg := msg.(type) {
+	case paneMsg:
+		if msg.pane < len(m.panes) {
+			return m, m.updatePane(msg.pane, msg.msg)
+		}
+		return m, nil
+
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.prompt.SetWidth(max(m.width-len(m.prompt.Prompt)-1, 1))
+		return m, m.resize()
+
+	case tea.KeyPressMsg:
+		if m.adding {
+			return m.updateAddPrompt(msg)
+		}
+		if p := m.panes[m.focus]; p.promptKind == promptNone && !p.visual {
+			switch {
+			case key.Matches(msg, p.keymap.nextPane):
+				m.setFocus((m.focus + 1) % len(m.panes))
+				return m, nil
+			case key.Matches(msg, p.keymap.prevPane):
+				m.setFocus((m.focus + len(m.panes) - 1) % len(m.panes))
+				return m, nil
+			case key.Matches(msg, p.keymap.addTab):
+				return m, m.openAddPrompt()
+			}
+		}
+		return m, m.updatePane(m.focus, msg)
+
+	case tea.PasteMsg:
+		if m.adding {
+			var cmd tea.Cmd
+			m.prompt, cmd = m.prompt.Update(msg)
+			return m, cmd
+		}
+		return m, m.updatePane(m.focus, msg)
+
+	case tea.MouseClickMsg, tea.MouseWheelMsg:
+		mouse := msg.(tea.MouseMsg).Mouse()
+		if m.layout == LayoutTabs && mouse.Y == 0 {
+			if i := m.tabAt(mouse.X); i >= 0 {
+				m.setFocus(i)
+			}
+			return m, nil
+		}
+		i := m.paneAt(mouse.X, mouse.Y)
+		if i < 0 {
+			return m, nil
+		}
+		if _, ok := msg.(tea.MouseClickMsg); ok {
+			m.setFocus(i)
+		}
+		return m, m.updatePane(i, m.paneMouse(i, msg.(tea.MouseMsg)))
+
+	case tea.MouseMotionMsg, tea.MouseReleaseMsg:
+		// A drag belongs to the pane it started in, even past its edges.
+		return m, m.updatePane(m.focus, m.paneMouse(m.focus, msg.(tea.MouseMsg)))
+	}
+
+	cmds := make([]tea.Cmd, len(m.panes)+1)
+	for i := range m.panes {
+		cmds[i] = m.updatePane(i, msg)
+	}
+	if m.adding {
+		m.prompt, cmds[len(m.panes)] = m.prompt.Update(msg)
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// resize gives every pane its share of the screen.
+func (m *Multi) resize() tea.Cmd {
+	cmds := make([]tea.Cmd, len(m.panes))
+	for i := range m.panes {
+		_, _, w, h := m.paneRect(i)
+		cmds[i] = m.updatePane(i, tea.WindowSizeMsg{Width: w, Height: h})
+	}
+	return tea.Batch(cmds...)
+}
+
+// updatePane passes msg to pane i and tags the commands it returns.
+func (m *Multi) updatePane(i int, msg tea.Msg) tea.Cmd {
+	model, cmd := m.panes[i].Update(msg)
+	m.panes[i] = model.(Model)
+	return tagCmd(i, cmd)
+}
+
+func (m *Multi) setFocus(i int) {
+	m.focus = i
+	for j := range m.panes {
+		m.panes[j].unfocused = j != i
+	}
+}
+
+// tagCmd wraps the messages a pane sends itself in a paneMsg for pane. Other
+// messages, such as quitting or setting the clipboard, are meant for the
+// program and are passed through.
+func tagCmd(pane int, cmd tea.Cmd) tea.Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		switch msg := cmd().(type) {
+		case tea.BatchMsg:
+			cmds := make(tea.BatchMsg, len(msg))
+			for i, c := range msg {
+				cmds[i] = tagCmd(pane, c)
+			}
+			return cmds
+		case runCmd, updateStdOut, clipboardNotification, resizeRerun, cmdData, cmdChunk, streamTick,
+			timer.TickMsg, timer.StartStopMsg, timer.TimeoutMsg:
+			return paneMsg{pane: pane, msg: msg}
+		default:
+			return msg
+		}
+	}
+}
+
+// paneRect returns the position and the size of pane i on the screen. In
+// columns, panes are separated by a column; tabs all take the screen below
+// the tab bar.
+func (m Multi) paneRect(i int) (x, y, w, h int) {
+	n := len(m.panes)
+	switch m.layout {
+	case LayoutTabs:
+		return 0, tabBarHeight, m.width, max(0, m.height-tabBarHeight)
+	case LayoutColumns:
+		w = (m.width - (n - 1)) / n
+		x = i * (w + 1)
+		if i == n-1 {
+			w = m.width - x
+		}
+		return x, 0, w, m.height
+	}
+	h = m.height / n
+	y = i * h
+	if i == n-1 {
+		h = m.height - y
+	}
+	return 0, y, m.width, h
+}
+
+// visible returns whether pane i is displayed.
+func (m Multi) visible(i int) bool {
+	return m.layout != LayoutTabs || i == m.focus
+}
+
+// paneAt returns the pane displayed at x, y, or -1 on a separator or the
+// tab bar.
+func (m Multi) paneAt(x, y int) int {
+	for i := range m.panes {
+		px, py, w, h := m.paneRect(i)
+		if m.visible(i) && x >= px && x < px+w && y >= py && y < py+h {
+			return i
+		}
+	}
+	return -1
+}
+
+// paneMouse returns msg with the coordinates relative to pane i.
+func (m Multi) paneMouse(i int, msg tea.MouseMsg) tea.Msg {
+	x, y, _, _ := m.paneRect(i)
+	mouse := msg.Mouse()
+	mouse.X -= x
+	mouse.Y -= y
+	switch msg.(type) {
+	case tea.MouseClickMsg:
+		return tea.MouseClickMsg(mouse)
+	case tea.MouseReleaseMsg:
+		return tea.MouseReleaseMsg(mouse)
+	case tea.MouseWheelMsg:
+		return tea.MouseWheelMsg(mouse)
+	default:
+		return tea.MouseMotionMsg(mouse)
+	}
+}
+
+func (m Multi) View() tea.View {
+	blocks := make([][]string, len(m.panes))
+	mouse := false
+	for i, p := range m.panes {
+		mouse = mouse || p.cfg.Mouse
+		if m.visible(i) {
+			_, _, w, h := m.paneRect(i)
+			blocks[i] = fitBlock(p.View().Content, w, h)
+		}
+	}
+
+	var lines []string
+	switch m.layout {
+	case LayoutTabs:
+		lines = append(fitBlock(m.tabBar(), m.width, tabBarHeight), blocks[m.focus]...)
+	case LayoutColumns:
+		sep := lipgloss.NewStyle().Foreground(m.panes[0].cfg.Theme.GutterColor).Render("│")
+		for y := 0; y < m.height; y++ {
+			row := make([]string, len(blocks))
+			for i, b := range blocks {
+				row[i] = b[y]
+			}
+			lines = append(lines, strings.Join(row, sep))
+		}
+	default:
+		for _, b := range blocks {
+			lines = append(lines, b...)
+		}
+	}
+	if m.adding && len(lines) > 0 {
+		lines[len(lines)-1] = fitBlock(m.prompt.View(), m.width, 1)[0]
+	}
+
+	v := tea.NewView(strings.Join(lines, "\n"))
+	v.AltScreen = true
+	if mouse {
+		v.MouseMode = tea.MouseModeCellMotion
+	}
+	return v
+}
+
+// fitBlock returns the lines of s cut or padded to exactly w columns and h
+// lines.
+func fitBlock(s string, w, h int) []string {
+	lines := strings.Split(s, "\n")
+	out := make([]string, h)
+	for i := range out {
+		var line string
+		if i < len(lines) {
+			line = ansi.Truncate(lines[i], w, "")
+		}
+		out[i] = line + ansi.ResetStyle + strings.Repeat(" ", max(0, w-ansi.StringWidth(line)))
+	}
+	return out
+}
+
+// ParseCommandSpec splits a command specification, [INTERVAL:]COMMAND, where
+// INTERVAL is a number of seconds or a duration such as 1m30s, floored at 1s.
+// Without a valid interval before the first colon, the whole specification
+// is the command, run every def.
+func ParseCommandSpec(s string, def time.Duration) (time.Duration, string, error) {
+	interval, command := def, s
+	if prefix, rest, ok := strings.Cut(s, ":"); ok {
+		if n, err := strconv.ParseUint(prefix, 10, 32); err == nil {
+			interval, command = time.Duration(n)*time.Second, rest
+		} else if d, err := time.ParseDuration(prefix); err == nil {
+			interval, command = d, rest
+		}
+	}
+	command = strings.TrimSpace(command)
+	if command == "" {
+		return 0, "", fmt.Errorf("empty command")
+	}
+	return max(interval, time.Second), command, nil
+}
@@ -0,0 +1,195 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fabio42/sasqwatch/ui/theme"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+)
+
+func newTestMulti(layout string, cmds ...string) Multi {
+	cfgs := make([]Config, len(cmds))
+	for i, c := range cmds {
+		cfgs[i] = newTestModel(5).cfg
+		cfgs[i].Cmd = c
+		cfgs[i].Theme = theme.DefaultTheme()
+	}
+	m := NewMulti(cfgs, layout)
+	model, _ := m.Update(tea.WindowSizeMsg{Width: 81, Height: 24})
+	return model.(Multi)
+}
+
+func TestTagCmd(t *testing.T) {
+	if tagCmd(1, nil) != nil {
+		t.Fatalf("expected no command for nil")
+	}
+	if msg, ok := tagCmd(1, runCmdEvent)().(paneMsg); !ok || msg.pane != 1 || msg.msg != (runCmd{}) {
+		t.Fatalf("expected the message to be tagged with the pane, got %#v", msg)
+	}
+	if _, ok := tagCmd(1, tea.Quit)().(tea.QuitMsg); !ok {
+		t.Fatalf("expected the quit message to be passed through")
+	}
+	batch, ok := tagCmd(2, tea.Batch(runCmdEvent, updateStdOutEvent))().(tea.BatchMsg)
+	if !ok || len(batch) != 2 {
+		t.Fatalf("expected a batch, got %#v", batch)
+	}
+	if msg := batch[1]().(paneMsg); msg.pane != 2 || msg.msg != (updateStdOut{}) {
+		t.Fatalf("expected the batched messages to be tagged, got %#v", msg)
+	}
+}
+
+func TestMulti_PaneSizes(t *testing.T) {
+	m := newTestMulti(LayoutRows, "a", "b", "c")
+	if m.panes[0].height != 8 || m.panes[2].height != 8 || m.panes[0].width != 81 {
+		t.Fatalf("expected stacked panes sharing the height, got %dx%d", m.panes[0].width, m.panes[0].height)
+	}
+
+	m = newTestMulti(LayoutColumns, "a", "b")
+	if m.panes[0].width != 40 || m.panes[1].width != 40 || m.panes[1].height != 24 {
+		t.Fatalf("expected side by side panes sharing the width, got %d and %d", m.panes[0].width, m.panes[1].width)
+	}
+	if got := m.paneAt(40, 3); got != -1 {
+		t.Fatalf("expected the separator at column 40, got pane %d", got)
+	}
+	if got := m.paneAt(41, 3); got != 1 {
+		t.Fatalf("expected the second pane at column 41, got %d", got)
+	}
+}
+
+func TestMulti_KeysGoToTheFocusedPane(t *testing.T) {
+	m := newTestMulti(LayoutRows, "a", "b")
+	if m.panes[0].unfocused || !m.panes[1].unfocused {
+		t.Fatalf("expected the first pane to have the focus")
+	}
+
+	model, _ := m.Update(tea.KeyPressMsg{Code: tea.KeyTab})
+	m = model.(Multi)
+	if m.focus != 1 || !m.panes[0].unfocused || m.panes[1].unfocused {
+		t.Fatalf("expected tab to move the focus to the second pane")
+	}
+
+	model, _ = m.Update(tea.KeyPressMsg{Code: tea.KeySpace, Text: " "})
+	m = model.(Multi)
+	if m.panes[0].paused || !m.panes[1].paused {
+		t.Fatalf("expected only the focused pane to be paused")
+	}
+
+	model, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyTab, Mod: tea.ModShift})
+	if model.(Multi).focus != 0 {
+		t.Fatalf("expected shift+tab to move the focus back")
+	}
+}
+
+func TestMulti_PaneKeysAreRebindable(t *testing.T) {
+	cfg := newTestModel(5).cfg
+	cfg.Theme = theme.DefaultTheme()
+	cfg.Keys = map[string][]string{"nextPane": {"ctrl+w"}}
+	b := cfg
+	b.Cmd = "b"
+	m := NewMulti([]Config{cfg, b}, LayoutRows)
+
+	model, _ := m.Update(tea.KeyPressMsg{Code: tea.KeyTab})
+	if model.(Multi).focus != 0 {
+		t.Fatalf("expected tab to be unbound")
+	}
+	model, _ = model.Update(tea.KeyPressMsg{Code: 'w', Mod: tea.ModCtrl})
+	if model.(Multi).focus != 1 {
+		t.Fatalf("expected ctrl+w to move the focus to the second pane")
+	}
+}
+
+func TestMulti_ClipboardCommandsAreNotTagged(t *testing.T) {
+	m := newTestMulti(LayoutRows, "a", "b")
+	m.panes[1].cfg.Clip = osc52Clipboard{}
+	model, _ := m.Update(paneMsg{pane: 1, msg: cmdDataWith("only b", 0)})
+	model, _ = model.Update(tea.KeyPressMsg{Code: tea.KeyTab})
+	_, cmd := model.Update(tea.KeyPressMsg{Code: 'y', Text: "y"})
+
+	// Commands waiting on a run or a timer never return here.
+	var msgs []tea.Msg
+	var collect func(tea.Cmd)
+	collect = func(cmd tea.Cmd) {
+		if cmd == nil {
+			return
+		}
+		ch := make(chan tea.Msg, 1)
+		go func() { ch <- cmd() }()
+		select {
+		case msg := <-ch:
+			if batch, ok := msg.(tea.BatchMsg); ok {
+				for _, c := range batch {
+					collect(c)
+				}
+				return
+			}
+			msgs = append(msgs, msg)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	collect(cmd)
+	for _, msg := range msgs {
+		if msg == tea.SetClipboard("only b")() {
+			return
+		}
+	}
+	t.Fatalf("expected the clipboard message to reach the program untagged, got %#v", msgs)
+}
+
+func TestMulti_PaneMessagesAreRouted(t *testing.T) {
+	m := newTestMulti(LayoutRows, "a", "b")
+	model, _ := m.Update(paneMsg{pane: 1, msg: cmdDataWith("only b", 0)})
+	m = model.(Multi)
+	if m.panes[0].cmdRecords != 0 || m.panes[1].cmdRecords != 1 {
+		t.Fatalf("expected the record in the second pane only")
+	}
+}
+
+func TestMulti_ClickFocusesThePane(t *testing.T) {
+	m := newTestMulti(LayoutColumns, "a", "b")
+	model, _ := m.Update(tea.MouseClickMsg{X: 60, Y: 5, Button: tea.MouseLeft})
+	if model.(Multi).focus != 1 {
+		t.Fatalf("expected the click to focus the second pane")
+	}
+}
+
+func TestMulti_View(t *testing.T) {
+	for _, layout := range []string{LayoutRows, LayoutColumns} {
+		m := newTestMulti(layout, "a", "b")
+		lines := strings.Split(m.View().Content, "\n")
+		if len(lines) != 24 {
+			t.Fatalf("%s: expected 24 lines, got %d", layout, len(lines))
+		}
+		for i, l := range lines {
+			if w := lipgloss.Width(l); w != 81 {
+				t.Fatalf("%s: expected line %d to be 81 columns wide, got %d", layout, i, w)
+			}
+		}
+	}
+}
+
+func TestParseCommandSpec(t *testing.T) {
+	tests := []struct {
+		in       string
+		interval time.Duration
+		command  string
+	}{
+		{"kubectl get pods", 2 * time.Second, "kubectl get pods"},
+		{"5:kubectl get events", 5 * time.Second, "kubectl get events"},
+		{"1m30s: curl -s localhost/health", 90 * time.Second, "curl -s localhost/health"},
+		{"date +%H:%M", 2 * time.Second, "date +%H:%M"},
+		{"0:uptime", time.Second, "uptime"},
+	}
+	for _, tt := range tests {
+		interval, command, err := ParseCommandSpec(tt.in, 2*time.Second)
+		if err != nil || interval != tt.interval || command != tt.command {
+			t.Fatalf("ParseCommandSpec(%q): expected %v %q, got %v %q (%v)", tt.in, tt.interval, tt.command, interval, command, err)
+		}
+	}
+	if _, _, err := ParseCommandSpec("5: ", time.Second); err == nil {
+		t.Fatalf("expected an error for an empty command")
+	}
+}
@@ -0,0 +1,143 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// patchContext is the number of unchanged lines shown around every hunk.
+const patchContext = 3
+
+// patchLine is a line of a line diff: ' ' when unchanged, '-' when deleted
+// and '+' when inserted. old and new count the lines of each side preceding
+// it, and noEOL marks a last line without a trailing newline.
+type patchLine struct {
+	op       byte
+	text     string
+	old, new int
+	noEOL    bool
+}
+
+// viewedPatch returns the unified patch turning the record preceding the
+// viewed one into the viewed record. The oldest record is compared with an
+// empty output.
+func (m *Model) viewedPatch() string {
+	i := len(m.cmdsData) - 1 - m.cmdIdx
+	current := parseStyled(m.output(i)).text()
+	to := m.patchLabel(m.cmdsData[i])
+	if m.cmdIdx >= m.cmdRecords-1 {
+		return unifiedPatch("", current, m.cfg.Cmd, to)
+	}
+	before := parseStyled(m.output(i - 1)).text()
+	return unifiedPatch(before, current, m.patchLabel(m.cmdsData[i-1]), to)
+}
+
+// patchLabel names a record in a patch header after the command and the time
+// its output was first seen.
+func (m *Model) patchLabel(d cmdData) string {
+	return fmt.Sprintf("%s\t%s", m.cfg.Cmd, d.firstSeen().Format(time.DateTime))
+}
+
+// unifiedPatch returns the differences between before and current in the
+// unified format of diff -u, or an empty string when they are identical.
+func unifiedPatch(before, current, fromLabel, toLabel string) string {
+	lines := diffLines(before, current)
+	var out strings.Builder
+	for i := 0; i < len(lines); {
+		for i < len(lines) && lines[i].op == ' ' {
+			i++
+		}
+		if i == len(lines) {
+			break
+		}
+		start := max(0, i-patchContext)
+		end := i
+		for end < len(lines) {
+			if lines[end].op != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(lines) && lines[run].op == ' ' {
+				run++
+			}
+			if run == len(lines) || run-end > 2*patchContext {
+				end = min(end+patchContext, len(lines))
+				break
+			}
+			end = run
+		}
+
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromLabel, toLabel)
+		}
+		var oldCount, newCount int
+		for _, l := range lines[start:end] {
+			if l.op != '+' {
+				oldCount++
+			}
+			if l.op != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n",
+			hunkRange(lines[start].old, oldCount), hunkRange(lines[start].new, newCount))
+		for _, l := range lines[start:end] {
+			out.WriteByte(l.op)
+			out.WriteString(l.text)
+			out.WriteByte('\n')
+			if l.noEOL {
+				out.WriteString("\\ No newline at end of file\n")
+			}
+		}
+		i = end
+	}
+	return out.String()
+}
+
+// hunkRange formats the range of a hunk header for a hunk starting after
+// line before and spanning count lines.
+func hunkRange(before, count int) string {
+	start := before + 1
+	if count == 0 {
+		start = before
+	}
+	if count == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// diffLines returns the line diff between before and current.
+func diffLines(before, current string) []patchLine {
+	dmp := diffmatchpatch.New()
+	a, b, lines := dmp.DiffLinesToChars(before, current)
+	var out []patchLine
+	old, new := 0, 0
+	for _, d := range dmp.DiffCharsToLines(dmp.DiffMain(a, b, false), lines) {
+		op := byte(' ')
+		switch d.Type {
+		case diffmatchpatch.DiffDelete:
+			op = '-'
+		case diffmatchpatch.DiffInsert:
+			op = '+'
+		}
+		for _, text := range strings.SplitAfter(d.Text, "\n") {
+			if text == "" {
+				continue
+			}
+			line, eol := strings.CutSuffix(text, "\n")
+			out = append(out, patchLine{op: op, text: line, old: old, new: new, noEOL: !eol})
+			if op != '+' {
+				old++
+			}
+			if op != '-' {
+				new++
+			}
+		}
+	}
+	return out
+}
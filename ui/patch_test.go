@@ -0,0 +1,41 @@
+package ui
+
+import "testing"
+
+func TestUnifiedPatch(t *testing.T) {
+	before := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\n"
+	current := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\n"
+	want := "--- old\n+++ new\n" +
+		"@@ -1,5 +1,5 @@\n a\n-b\n+B\n c\n d\n e\n" +
+		"@@ -9,3 +9,4 @@\n i\n j\n k\n+l\n"
+	if got := unifiedPatch(before, current, "old", "new"); got != want {
+		t.Fatalf("expected\n%s\ngot\n%s", want, got)
+	}
+}
+
+func TestUnifiedPatch_IdenticalIsEmpty(t *testing.T) {
+	if got := unifiedPatch("a\nb", "a\nb", "old", "new"); got != "" {
+		t.Fatalf("expected empty patch, got %q", got)
+	}
+}
+
+func TestUnifiedPatch_FromEmpty(t *testing.T) {
+	want := "--- old\n+++ new\n@@ -0,0 +1,2 @@\n+a\n+b\n"
+	if got := unifiedPatch("", "a\nb\n", "old", "new"); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestUnifiedPatch_NoNewlineAtEndOfFile(t *testing.T) {
+	tests := []struct{ before, current, want string }{
+		{"a\nb", "a\nb\n", "@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n"},
+		{"a\nb\n", "a\nc", "@@ -1,2 +1,2 @@\n a\n-b\n+c\n\\ No newline at end of file\n"},
+		{"a\nb", "x\nb", "@@ -1,2 +1,2 @@\n-a\n+x\n b\n\\ No newline at end of file\n"},
+	}
+	for _, tt := range tests {
+		want := "--- old\n+++ new\n" + tt.want
+		if got := unifiedPatch(tt.before, tt.current, "old", "new"); got != want {
+			t.Fatalf("%q -> %q: expected %q, got %q", tt.before, tt.current, want, got)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package ui
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+)
+
+const (
+	promptNone = iota
+	promptSearch
+	promptSearchBack
+	promptFilter
+	promptGoto
+)
+
+// openPrompt shows the one-line input prompt in place of the help line.
+func (m *Model) openPrompt(kind int, prefix string) tea.Cmd {
+	m.promptKind = kind
+	m.prompt = textinput.New()
+	m.prompt.Prompt = prefix
+	m.prompt.SetWidth(max(m.width-len(prefix)-1, 1))
+	if m.printHelp {
+		m.printHelp = false
+		m.viewport.Height = m.viewportHeight()
+	}
+	m.promptOrigin = m.viewport.YOffset
+	m.promptPrevSearch = m.viewport.Search()
+	m.promptPrevFilter, m.promptPrevNeg = m.filter, m.filterNeg
+	return m.prompt.Focus()
+}
+
+// updatePrompt handles key presses while the prompt is open. Searches and
+// filters are incremental: the pattern is applied on every keystroke, enter
+// keeps it and esc restores the previous one.
+func (m Model) updatePrompt(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		var cmd tea.Cmd
+		switch {
+		case m.isSearchPrompt():
+			m.viewport.SetSearch(m.promptPrevSearch)
+			m.viewport.SetYOffset(m.promptOrigin)
+		case m.promptKind == promptFilter:
+			m.setFilter(m.promptPrevFilter, m.promptPrevNeg)
+			cmd = updateStdOutEvent
+		}
+		m.promptKind = promptNone
+		return m, cmd
+	case "enter":
+		kind := m.promptKind
+		m.promptKind = promptNone
+		if kind == promptGoto {
+			m.gotoLine(m.prompt.Value())
+			return m, nil
+		}
+		if m.isSearchKind(kind) {
+			m.searchBack = kind == promptSearchBack
+			if m.prompt.Value() == "" {
+				m.viewport.SetSearch(nil)
+			}
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.prompt, cmd = m.prompt.Update(msg)
+	if m.promptKind == promptFilter {
+		value := m.prompt.Value()
+		neg := strings.HasPrefix(value, "!")
+		m.setFilter(compilePattern(strings.TrimPrefix(value, "!")), neg)
+		return m, tea.Batch(cmd, updateStdOutEvent)
+	}
+	if m.isSearchPrompt() {
+		m.viewport.SetYOffset(m.promptOrigin)
+		m.viewport.SetSearch(compilePattern(m.prompt.Value()))
+		if m.promptKind == promptSearchBack {
+			m.viewport.SearchPrev()
+		} else {
+			m.viewport.SearchNext()
+		}
+	}
+	return m, cmd
+}
+
+func (m Model) isSearchPrompt() bool {
+	return m.isSearchKind(m.promptKind)
+}
+
+func (m Model) isSearchKind(kind int) bool {
+	return kind == promptSearch || kind == promptSearchBack
+}
+
+// setFilter changes the line filter. The perpetual diff baseline is reset
+// since it was computed over the previously filtered lines.
+func (m *Model) setFilter(re *regexp.Regexp, neg bool) {
+	m.filter, m.filterNeg = re, neg && re != nil
+	m.cmdPerpDiff, m.cmdPerpBase = "", ""
+}
+
+// compilePattern compiles a user supplied pattern. Patterns without upper
+// case letters match case-insensitively, and patterns that are not valid
+// regular expressions are matched literally. An empty pattern returns nil.
+func compilePattern(pattern string) *regexp.Regexp {
+	if pattern == "" {
+		return nil
+	}
+	expr := pattern
+	if _, err := regexp.Compile(expr); err != nil {
+		expr = regexp.QuoteMeta(pattern)
+	}
+	if !strings.ContainsFunc(pattern, unicode.IsUpper) {
+		expr = "(?i)" + expr
+	}
+	return regexp.MustCompile(expr)
+}
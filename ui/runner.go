@@ -0,0 +1,274 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fabio42/sasqwatch/vterm"
+
+	"github.com/creack/pty"
+	"github.com/rs/zerolog/log"
+)
+
+// CommandRunner abstracts shell execution so the model can be tested without spawning processes.
+type CommandRunner interface {
+	Run(req RunRequest) RunResult
+}
+
+// RunRequest describes a run of the watched command.
+type RunRequest struct {
+	Command    string
+	Cols, Rows int      // size of the terminal the command runs on
+	Env        []string // KEY=VALUE pairs added to the environment of sasqwatch
+	Dir        string   // working directory; empty means the current one
+	Stdin      string   // file read on the standard input; empty means none
+
+	// Stream, when set, is called with every chunk of output as it is read,
+	// from the goroutine running the command. The chunk is only valid
+	// during the call.
+	Stream func(chunk []byte)
+}
+
+// RunResult is the outcome of a run of the watched command.
+type RunResult struct {
+	Stdout    []byte
+	ExitCode  int
+	Usage     ResourceUsage
+	Truncated bool // part of the output was dropped, see --max-output
+}
+
+// ResourceUsage is the CPU time and memory used by a run, including the
+// processes it waited for. It is zero when the platform does not report it.
+type ResourceUsage struct {
+	User, Sys time.Duration
+	MaxRSS    int64 // peak resident set size in bytes
+}
+
+// resultOf returns the result of cmd once it exited with err.
+func resultOf(cmd *exec.Cmd, out []byte, truncated bool, err error) RunResult {
+	res := RunResult{Stdout: out, Truncated: truncated}
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		res.ExitCode = exitErr.ExitCode()
+	}
+	if cmd.ProcessState != nil {
+		res.Usage = ResourceUsage{
+			User:   cmd.ProcessState.UserTime(),
+			Sys:    cmd.ProcessState.SystemTime(),
+			MaxRSS: maxRSS(cmd.ProcessState),
+		}
+	}
+	return res
+}
+
+// Resizer is implemented by runners able to resize the terminal of a command
+// still running, so it can redraw at the new size.
+type Resizer interface {
+	Resize(cols, rows int)
+}
+
+// shellRunner is the production implementation of CommandRunner.
+// When usePty is true it allocates a pseudo-terminal sized to cols×rows so that
+// terminal-aware programs (e.g. tools that draw width-adaptive tables) see a
+// real TTY and format their output correctly; the output is then the final
+// screen of a virtual terminal of the same size. Falls back to plain pipe-based
+// CombinedOutput if PTY allocation fails (e.g. CI / constrained environments).
+// When usePty is false (the default) the command runs on ordinary pipes.
+// At most maxOutput bytes of the output are kept, from the parts selected by
+// keep; a maxOutput of 0 keeps everything. In PTY mode the limit applies to
+// the rendered screen and the virtual terminal only keeps the head of the
+// stream with KeepHead, the tail otherwise.
+// The command is interpreted by shell, or sh when empty; when argv is set it
+// is executed directly instead and the command string is ignored.
+type shellRunner struct {
+	usePty    bool
+	shell     string
+	argv      []string
+	maxOutput int
+	keep      string
+	running   *runningPty // the PTY of the command in progress, see Resize
+}
+
+// command returns the process running command.
+func (r shellRunner) command(command string) *exec.Cmd {
+	if len(r.argv) > 0 {
+		return exec.Command(r.argv[0], r.argv[1:]...)
+	}
+	shell := r.shell
+	if shell == "" {
+		shell = "sh"
+	}
+	return exec.Command(shell, "-c", command)
+}
+
+// prepare returns the process described by req, and the file opened for its
+// standard input, if any, to close once the process is done.
+func (r shellRunner) prepare(req RunRequest) (cmd *exec.Cmd, stdin *os.File, err error) {
+	cmd = r.command(req.Command)
+	cmd.Env = append(os.Environ(), req.Env...)
+	cmd.Dir = req.Dir
+	if req.Stdin == "" {
+		return cmd, nil, nil
+	}
+	if stdin, err = os.Open(req.Stdin); err != nil {
+		return nil, nil, err
+	}
+	cmd.Stdin = stdin
+	return cmd, stdin, nil
+}
+
+// runningPty is the PTY and the virtual terminal of a running command.
+type runningPty struct {
+	mu   sync.Mutex
+	ptmx *os.File
+	term *vterm.Terminal
+
+	limit int64 // bytes fed to term before discarding the rest; 0 feeds all
+	fed   int64
+	read  int64
+}
+
+// reset gives p a blank virtual terminal of cols×rows for a new run, keeping
+// about maxOutput bytes of the parts selected by keep.
+func (p *runningPty) reset(cols, rows, maxOutput int, keep string) {
+	p.term = vterm.New(cols, rows)
+	p.fed, p.read, p.limit = 0, 0, 0
+	if maxOutput > 0 {
+		if keep == KeepHead {
+			p.limit = int64(maxOutput)
+		} else {
+			// Keep about maxOutput bytes of full lines in the scrollback,
+			// the rendered output is cut to the limit afterwards.
+			p.term.MaxScrollback = max(maxOutput/max(cols, 1), 1)
+		}
+	}
+}
+
+// Write feeds the virtual terminal, serialized with Resize. Bytes past the
+// limit are discarded but still read, so the command is not blocked.
+func (p *runningPty) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := len(b)
+	p.read += int64(n)
+	if p.limit > 0 {
+		b = b[:min(int64(n), max(p.limit-p.fed, 0))]
+	}
+	p.fed += int64(len(b))
+	p.term.Write(b)
+	return n, nil
+}
+
+// output returns the rendered screen cut to limit bytes, with markers where
+// lines left the scrollback or bytes were discarded, and whether any output
+// was dropped.
+func (p *runningPty) output(limit int, keep string) ([]byte, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	w := newLimitWriter(limit, keep)
+	io.WriteString(w, p.term.String())
+	out, truncated := w.Bytes()
+	dropped := p.term.Dropped()
+	if dropped > 0 {
+		out = append([]byte(truncationMarker(fmt.Sprintf("%d lines", dropped))+"\n"), out...)
+	}
+	if p.read > p.fed {
+		out = append(out, "\n"+truncationMarker(formatBytes(p.read-p.fed))...)
+	}
+	return out, truncated || dropped > 0 || p.read > p.fed
+}
+
+// Resize propagates the new size to the PTY of the running command, which
+// gets a SIGWINCH, and to the virtual terminal rendering its output.
+func (r shellRunner) Resize(cols, rows int) {
+	if r.running == nil {
+		return
+	}
+	r.running.mu.Lock()
+	defer r.running.mu.Unlock()
+	if r.running.ptmx == nil {
+		return
+	}
+	if err := pty.Setsize(r.running.ptmx, &pty.Winsize{Rows: uint16(rows), Cols: uint16(cols)}); err != nil {
+		log.Debug().Str("function", "shellRunner.Resize").Msgf("pty resize failed: %v", err)
+		return
+	}
+	r.running.term.Resize(cols, rows)
+}
+
+func (r shellRunner) Run(req RunRequest) RunResult {
+	cmd, stdin, err := r.prepare(req)
+	if err != nil {
+		return RunResult{Stdout: []byte("sasqwatch: " + err.Error()), ExitCode: 1}
+	}
+	if stdin != nil {
+		defer stdin.Close()
+	}
+	cols, rows := req.Cols, req.Rows
+
+	if !r.usePty {
+		return r.runPipes(cmd, req.Stream)
+	}
+
+	ptmx, err := pty.StartWithSize(cmd, &pty.Winsize{
+		Rows: uint16(rows),
+		Cols: uint16(cols),
+	})
+	if err != nil {
+		// PTY unavailable — fall back to ordinary pipes.
+		log.Debug().Str("function", "shellRunner.Run").Msgf("pty start failed, falling back to pipes: %v", err)
+		return r.runPipes(cmd, req.Stream)
+	}
+	defer ptmx.Close()
+
+	// The output is interpreted by a virtual terminal of the PTY size so
+	// cursor movements, carriage returns and full screen programs render as
+	// they would on screen.
+	p := r.running
+	if p == nil {
+		p = &runningPty{}
+	}
+	p.mu.Lock()
+	p.ptmx = ptmx
+	p.reset(cols, rows, r.maxOutput, r.keep)
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		p.ptmx = nil
+		p.mu.Unlock()
+	}()
+	var dst io.Writer = p
+	if req.Stream != nil {
+		dst = io.MultiWriter(p, streamWriter(req.Stream))
+	}
+	_, copyErr := io.Copy(dst, ptmx)
+	// On macOS (and some Linux kernels) the PTY master returns EIO once the
+	// child's slave side is closed — that is normal EOF, not a real error.
+	if copyErr != nil && !errors.Is(copyErr, syscall.EIO) {
+		log.Debug().Str("function", "shellRunner.Run").Msgf("pty read error: %v", copyErr)
+	}
+
+	waitErr := cmd.Wait()
+	out, truncated := p.output(r.maxOutput, r.keep)
+	return resultOf(cmd, out, truncated, waitErr)
+}
+
+// runPipes runs cmd with its standard and error outputs combined on a pipe,
+// keeping the output within the limit while it is read. stream, when set,
+// gets every chunk read.
+func (r shellRunner) runPipes(cmd *exec.Cmd, stream func([]byte)) RunResult {
+	w := newLimitWriter(r.maxOutput, r.keep)
+	var dst io.Writer = w
+	if stream != nil {
+		dst = io.MultiWriter(w, streamWriter(stream))
+	}
+	cmd.Stdout, cmd.Stderr = dst, dst
+	err := cmd.Run()
+	out, truncated := w.Bytes()
+	return resultOf(cmd, out, truncated, err)
+}
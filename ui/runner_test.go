@@ -0,0 +1,109 @@
+package ui
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/fabio42/sasqwatch/vterm"
+)
+
+func TestShellRunner_Command(t *testing.T) {
+	cmd := shellRunner{}.command("echo hi")
+	if got := strings.Join(cmd.Args, " "); got != "sh -c echo hi" {
+		t.Fatalf("expected sh by default, got %q", got)
+	}
+	cmd = shellRunner{shell: "/bin/bash"}.command("echo hi")
+	if got := cmd.Args; got[0] != "/bin/bash" || got[2] != "echo hi" {
+		t.Fatalf("expected the configured shell, got %q", got)
+	}
+	cmd = shellRunner{shell: "/bin/bash", argv: []string{"stat", "my file"}}.command("stat my file")
+	if got := cmd.Args; len(got) != 2 || got[1] != "my file" {
+		t.Fatalf("expected the arguments to be passed as is, got %q", got)
+	}
+}
+
+func TestShellRunner_Prepare(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "in")
+	if err := os.WriteFile(path, []byte("input"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cmd, stdin, err := shellRunner{}.prepare(RunRequest{Command: "cat", Env: []string{"A=1"}, Dir: "/tmp", Stdin: path})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer stdin.Close()
+	if cmd.Env[len(cmd.Env)-1] != "A=1" || cmd.Dir != "/tmp" || cmd.Stdin != stdin {
+		t.Fatalf("expected env, dir and stdin to be set, got %q %q %v", cmd.Env[len(cmd.Env)-1], cmd.Dir, cmd.Stdin)
+	}
+
+	if _, _, err := (shellRunner{}).prepare(RunRequest{Stdin: path + ".missing"}); err == nil {
+		t.Fatalf("expected an error for a missing stdin file")
+	}
+}
+
+func TestRunRequest_ExportsWatchContext(t *testing.T) {
+	m := newTestModel(5)
+	m.cfg.Env = []string{"A=1"}
+	m.width, m.height = 100, 30
+	m.runs = 1
+	req := m.runRequest()
+	want := []string{"A=1", "SASQWATCH_RUN=1", "SASQWATCH_COLUMNS=100", "SASQWATCH_LINES=27"}
+	if !slices.Equal(req.Env, want) {
+		t.Fatalf("expected %q, got %q", want, req.Env)
+	}
+
+	m.runs, m.lastExit = 2, 3
+	if env := m.runRequest().Env; !slices.Contains(env, "SASQWATCH_PREV_EXIT=3") {
+		t.Fatalf("expected the previous exit code from the second run, got %q", env)
+	}
+}
+
+func TestResultOf_ExitCodeAndUsage(t *testing.T) {
+	cmd := exec.Command("sh", "-c", "exit 3")
+	err := cmd.Run()
+	res := resultOf(cmd, nil, false, err)
+	if res.ExitCode != 3 {
+		t.Fatalf("expected exit code 3, got %d", res.ExitCode)
+	}
+	if runtime.GOOS == "linux" && res.Usage.MaxRSS == 0 {
+		t.Fatalf("expected the peak RSS to be reported")
+	}
+}
+
+func TestShellRunner_MaxOutput(t *testing.T) {
+	r := shellRunner{maxOutput: 64, keep: KeepTail}
+	res := r.Run(RunRequest{Command: "seq 1 10000"})
+	out := string(res.Stdout)
+	if !res.Truncated || !strings.HasPrefix(out, "[… output truncated") || !strings.HasSuffix(out, "\n10000\n") {
+		t.Fatalf("expected the tail to be kept, got %v %q", res.Truncated, out)
+	}
+	if len(out) > 64+len(truncationMarker("48.8KiB"))+1 {
+		t.Fatalf("expected the output to be limited, got %d bytes", len(out))
+	}
+}
+
+func TestRunningPty_DiscardsPastLimit(t *testing.T) {
+	p := &runningPty{term: vterm.New(20, 3), limit: 4}
+	if n, _ := p.Write([]byte("abc\r\ndef")); n != 8 {
+		t.Fatalf("expected every byte to be consumed, got %d", n)
+	}
+	out, truncated := p.output(0, KeepHead)
+	if want := "abc\n" + truncationMarker("4B"); string(out) != want || !truncated {
+		t.Fatalf("expected %q, got %q %v", want, out, truncated)
+	}
+}
+
+func TestShellRunner_Stream(t *testing.T) {
+	var streamed []byte
+	res := shellRunner{}.Run(RunRequest{Command: "echo one; echo two >&2", Stream: func(chunk []byte) {
+		streamed = append(streamed, chunk...)
+	}})
+	if string(streamed) != "one\ntwo\n" || string(res.Stdout) != "one\ntwo\n" {
+		t.Fatalf("expected the output to be streamed, got %q and %q", streamed, res.Stdout)
+	}
+}
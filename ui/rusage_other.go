@@ -0,0 +1,10 @@
+//go:build !unix
+
+package ui
+
+import "os"
+
+// maxRSS is not reported on this platform.
+func maxRSS(*os.ProcessState) int64 {
+	return 0
+}
@@ -2,16 +2,69 @@ package ui
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/fabio42/sasqwatch/viewport"
+
 	"charm.land/lipgloss/v2"
 )
 
 const statusHeight = 2
 
+// Clickable segments of the status bar, see statusZoneAt.
+const (
+	zoneNone = iota
+	zoneMode
+	zoneRecords
+	zoneDiff
+)
+
 // statusView generates the status bar rendered as a single-line string.
 func (m *Model) statusView() string {
-	var left, right, modeData, clip, diff, records, date string
+	var left, right, date string
+	t := m.cfg.Theme
+	mainStyle := lipgloss.NewStyle().Background(t.StatusBgColor).Foreground(t.StatusFgColor)
+
+	cmd := m.cmdsData[len(m.cmdsData)-1]
+	if m.paused {
+		cmd = m.cmdsData[len(m.cmdsData)-1-m.cmdIdx]
+	}
+	// On start the date is unset until the first command execution completes.
+	if cmd.date.Equal(time.Time{}) && m.firstRun {
+		cmd.date = time.Now()
+	}
+	date = fmt.Sprintf("%s: %s", m.cfg.HostName, cmd.date.Format("Mon Jan 02 15:04:05 2006"))
+	left = strings.Join(m.statusSegments(), "")
+
+	left = m.truncStatus(left, len([]rune(date)))
+
+	if len([]rune(left+date)) > m.width {
+		date = m.truncStatus(date, 1)
+	}
+
+	right = mainStyle.Width(m.width - (lipgloss.Width(left))).AlignHorizontal(lipgloss.Right).Render(date)
+	return left + right
+}
+
+// statusZoneAt returns the clickable segment of the status bar displayed at
+// column x, or zoneNone.
+func (m *Model) statusZoneAt(x int) int {
+	segs := m.statusSegments()
+	for zone := zoneMode; zone <= zoneDiff; zone++ {
+		w := lipgloss.Width(segs[zone-zoneMode])
+		if x < w {
+			return zone
+		}
+		x -= w
+	}
+	return zoneNone
+}
+
+// statusSegments returns the rendered segments of the left part of the
+// status bar: mode, records and diff first, in the order of the zones.
+func (m *Model) statusSegments() []string {
+	var modeData, clip, diff, running, truncated, visual, scroll, wrap, filter, search, position, usage, records string
 	var cmd cmdData
 	t := m.cfg.Theme
 
@@ -43,30 +96,105 @@ func (m *Model) statusView() string {
 
 	if m.diffOption != diffOff {
 		var diffMode string
-		if m.diffOption == diffSimple {
+		switch m.diffOption {
+		case diffSimple:
 			diffMode = t.OptionSeparator + "diff "
-		} else {
+		case diffPerpetual:
 			diffMode = t.OptionSeparator + "permDiff "
+		case diffHeatmap:
+			diffMode = t.OptionSeparator + "heatmap "
+		default:
+			diffMode = t.OptionSeparator + "delta "
+		}
+		st := m.diffStats
+		diffMode += fmt.Sprintf("+%d/-%d lines +%d/-%d chars ", st.insLines, st.delLines, st.insChars, st.delChars)
+		switch {
+		case m.hunkIdx >= 0:
+			diffMode += fmt.Sprintf("%schange %d/%d ", t.OptionSeparator, m.hunkIdx+1, len(m.hunks))
+		case len(m.hunks) > 0:
+			diffMode += fmt.Sprintf("%s%d changes ", t.OptionSeparator, len(m.hunks))
+		}
+		if !cmd.changed.IsZero() {
+			diffMode += t.OptionSeparator + "changed " + time.Since(cmd.changed).Round(time.Second).String() + " ago "
 		}
 		diff = mainStyle.Foreground(t.StatusOptionColor).Render(diffMode)
 	}
 
-	// On start the date is unset until the first command execution completes.
-	if cmd.date.Equal(time.Time{}) && m.firstRun {
-		cmd.date = time.Now()
+	if m.cfg.Stream && m.inProgress {
+		running = mainStyle.Foreground(t.StatusRunColor).Render(fmt.Sprintf("%srunning… %s ",
+			t.OptionSeparator, time.Since(m.runStart).Round(time.Second)))
 	}
-	date = fmt.Sprintf("%s: %s", m.cfg.HostName, cmd.date.Format("Mon Jan 02 15:04:05 2006"))
-	mode := mainStyle.Background(bg).Foreground(t.StatusModeFgColor).AlignHorizontal(lipgloss.Left).Render(modeData)
-	left = mode + records + diff + clip
 
-	left = m.truncStatus(left, len([]rune(date)))
+	if cmd.truncated {
+		truncated = mainStyle.Foreground(t.StatusStopColor).Render(t.OptionSeparator + "output truncated ")
+	}
 
-	if len([]rune(left+date)) > m.width {
-		date = m.truncStatus(date, 1)
+	if m.visual {
+		visualMode := "VISUAL LINE"
+		if m.visualMode == viewport.SelectBlock {
+			visualMode = "VISUAL BLOCK"
+		}
+		visual = mainStyle.Foreground(t.StatusOptionColor).Render(t.OptionSeparator + visualMode + " ")
 	}
 
-	right = mainStyle.Width(m.width - (lipgloss.Width(left))).AlignHorizontal(lipgloss.Right).Render(date)
-	return left + right
+	switch m.viewport.ScrollMode {
+	case viewport.ScrollAnchor:
+		scroll = mainStyle.Foreground(t.StatusOptionColor).Render(t.OptionSeparator + "anchor ")
+	case viewport.ScrollFollow:
+		scroll = mainStyle.Foreground(t.StatusOptionColor).Render(t.OptionSeparator + "follow ")
+	}
+
+	if m.viewport.Wrap() {
+		wrap = mainStyle.Foreground(t.StatusOptionColor).Render(t.OptionSeparator + "wrap ")
+	}
+
+	if m.filter != nil {
+		filterData := t.OptionSeparator + "filter: "
+		if m.filterNeg {
+			filterData += "!"
+		}
+		filterData += strings.TrimPrefix(m.filter.String(), "(?i)") + " "
+		filter = mainStyle.Foreground(t.StatusOptionColor).Render(filterData)
+	}
+
+	if re := m.viewport.Search(); re != nil {
+		searchData := t.OptionSeparator + "/" + strings.TrimPrefix(re.String(), "(?i)")
+		if n := m.viewport.MatchCount(); m.viewport.CurrentMatch() >= 0 {
+			searchData += fmt.Sprintf(" %d/%d ", m.viewport.CurrentMatch()+1, n)
+		} else {
+			searchData += fmt.Sprintf(" %d matches ", n)
+		}
+		search = mainStyle.Foreground(t.StatusOptionColor).Render(searchData)
+	}
+
+	position = mainStyle.Foreground(t.StatusOptionColor).Render(fmt.Sprintf("%sline %d/%d %d%% ",
+		t.OptionSeparator, m.viewport.TopLine()+1, m.viewport.TotalLineCount(), int(m.viewport.ScrollPercent()*100)))
+
+	if u := cmd.usage; u != (ResourceUsage{}) {
+		usage = mainStyle.Foreground(t.StatusOptionColor).Render(fmt.Sprintf("%scpu %s user %s sys rss %s ",
+			t.OptionSeparator, u.User.Round(time.Millisecond), u.Sys.Round(time.Millisecond), formatBytes(u.MaxRSS)))
+	}
+
+	mode := mainStyle.Background(bg).Foreground(t.StatusModeFgColor).AlignHorizontal(lipgloss.Left).Render(modeData)
+	if m.unfocused {
+		// Panes without the focus show the mode in color on the bar instead.
+		mode = mainStyle.Foreground(bg).Render(modeData)
+	}
+	return []string{mode, records, diff, running, truncated, visual, scroll, wrap, filter, search, position, usage, clip}
+}
+
+// formatBytes formats n bytes with a binary unit, e.g. 12.5MiB.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(n)/float64(div), "KMGTPE"[exp])
 }
 
 // truncStatus truncates str so it fits within (m.width - width) columns,
@@ -3,6 +3,7 @@ package ui
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/fabio42/sasqwatch/ui/theme"
 )
@@ -55,3 +56,66 @@ func TestTruncStatus_Empty_Input(t *testing.T) {
 		t.Fatalf("expected empty result for empty input, got %q", result)
 	}
 }
+
+func TestStatusView_DiffMode_ShowsCountersAndLastChange(t *testing.T) {
+	m := newStatusModel(200)
+	m.firstRun = false
+	m.diffOption = diffSimple
+	m.procCmdData(cmdDataWith("a\nb\n", 0))
+	m.procCmdData(cmdDataWith("a\nc\n", 0))
+	m.diffStats = m.viewedDiffStats()
+
+	status := m.statusView()
+	if !strings.Contains(status, "+1/-1 lines +1/-1 chars") {
+		t.Fatalf("expected diff counters in status bar, got %q", status)
+	}
+	if !strings.Contains(status, "changed 0s ago") {
+		t.Fatalf("expected last change indicator in status bar, got %q", status)
+	}
+}
+
+func TestViewedDiffStats_PermDiffCountsFromTheFirstRecord(t *testing.T) {
+	m := newStatusModel(200)
+	m.diffOption = diffPerpetual
+	m.procCmdData(cmdDataWith("a\nb\n", 0))
+	m.firstRun = false
+	m.renderDiff()
+	m.procCmdData(cmdDataWith("a\nc\n", 0))
+	m.renderDiff()
+	m.procCmdData(cmdDataWith("d\nc\n", 0))
+
+	if st := m.viewedDiffStats(); st.insLines != 2 || st.delLines != 2 {
+		t.Fatalf("expected the counters against the first record, got +%d/-%d lines", st.insLines, st.delLines)
+	}
+	m.diffOption = diffSimple
+	if st := m.viewedDiffStats(); st.insLines != 1 || st.delLines != 1 {
+		t.Fatalf("expected the counters against the previous record, got +%d/-%d lines", st.insLines, st.delLines)
+	}
+}
+
+func TestStatusView_ResourceUsage(t *testing.T) {
+	m := newStatusModel(200)
+	m.cmdsData[len(m.cmdsData)-1].usage = ResourceUsage{User: 1500 * time.Millisecond, Sys: 20 * time.Millisecond, MaxRSS: 12 << 20}
+	if got := m.statusView(); !strings.Contains(got, "cpu 1.5s user 20ms sys rss 12.0MiB") {
+		t.Fatalf("expected resource usage in status, got %q", got)
+	}
+}
+
+func TestStatusView_Truncated(t *testing.T) {
+	m := newStatusModel(200)
+	if strings.Contains(m.statusView(), "output truncated") {
+		t.Fatalf("unexpected truncation marker")
+	}
+	m.cmdsData[len(m.cmdsData)-1].truncated = true
+	if got := m.statusView(); !strings.Contains(got, "output truncated") {
+		t.Fatalf("expected truncation marker in status, got %q", got)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	for n, want := range map[int64]string{512: "512B", 1536: "1.5KiB", 3 << 30: "3.0GiB"} {
+		if got := formatBytes(n); got != want {
+			t.Fatalf("formatBytes(%d): expected %q, got %q", n, want, got)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package ui
+
+import (
+	"sync"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+// streamInterval is how often the output read so far is sent to the model
+// in streaming mode.
+const streamInterval = 100 * time.Millisecond
+
+// cmdChunk is output of the run in progress, sent in streaming mode before
+// the cmdData ending the run.
+type cmdChunk []byte
+
+// streamTick refreshes the elapsed time of run while it is in progress.
+type streamTick struct{ run int }
+
+func streamTickEvent(run int) tea.Cmd {
+	return tea.Tick(time.Second, func(_ time.Time) tea.Msg { return streamTick{run: run} })
+}
+
+// streamWriter passes what is written to a RunRequest.Stream function.
+type streamWriter func(chunk []byte)
+
+func (w streamWriter) Write(p []byte) (int, error) {
+	w(p)
+	return len(p), nil
+}
+
+// chunkBatcher gathers the chunks streamed by a runner and sends them to the
+// model every streamInterval, so a chatty command does not flood it with
+// messages.
+type chunkBatcher struct {
+	mu      sync.Mutex
+	pending []byte
+	out     chan<- tea.Msg
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+func newChunkBatcher(out chan<- tea.Msg) *chunkBatcher {
+	b := &chunkBatcher{out: out, done: make(chan struct{}), stopped: make(chan struct{})}
+	go func() {
+		defer close(b.stopped)
+		t := time.NewTicker(streamInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				b.flush()
+			case <-b.done:
+				b.flush()
+				return
+			}
+		}
+	}()
+	return b
+}
+
+// add is the RunRequest.Stream function of the run.
+func (b *chunkBatcher) add(chunk []byte) {
+	b.mu.Lock()
+	b.pending = append(b.pending, chunk...)
+	b.mu.Unlock()
+}
+
+func (b *chunkBatcher) flush() {
+	b.mu.Lock()
+	chunk := b.pending
+	b.pending = nil
+	b.mu.Unlock()
+	if len(chunk) > 0 {
+		b.out <- cmdChunk(chunk)
+	}
+}
+
+// stop sends the last chunks and returns once they are delivered.
+func (b *chunkBatcher) stop() {
+	close(b.done)
+	<-b.stopped
+}
+
+// partialOutput accumulates the output of the run in progress within the
+// output limit, like the runner does. In PTY mode it is rendered by a
+// virtual terminal.
+type partialOutput struct {
+	out *limitWriter
+	pty *runningPty
+}
+
+func (m *Model) newPartialOutput() *partialOutput {
+	if !m.cfg.Pty {
+		return &partialOutput{out: newLimitWriter(m.cfg.MaxOutput, m.cfg.KeepOutput)}
+	}
+	p := &runningPty{}
+	cols, rows := m.ptySize()
+	p.reset(cols, rows, m.cfg.MaxOutput, m.cfg.KeepOutput)
+	return &partialOutput{pty: p}
+}
+
+func (p *partialOutput) Write(chunk []byte) {
+	if p.pty != nil {
+		p.pty.Write(chunk)
+		return
+	}
+	p.out.Write(chunk)
+}
+
+func (p *partialOutput) String() string {
+	var out []byte
+	if p.pty != nil {
+		out, _ = p.pty.output(0, "")
+	} else {
+		out, _ = p.out.Bytes()
+	}
+	return string(out)
+}
+
+// resize resizes the virtual terminal rendering the output in PTY mode.
+func (p *partialOutput) resize(cols, rows int) {
+	if p.pty != nil {
+		p.pty.mu.Lock()
+		p.pty.term.Resize(cols, rows)
+		p.pty.mu.Unlock()
+	}
+}
+
+// streaming returns whether the viewport shows the output of the run in
+// progress instead of the latest record.
+func (m *Model) streaming() bool {
+	return m.partial != nil && !m.paused
+}
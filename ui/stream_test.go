@@ -0,0 +1,94 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+// streamingRunner streams its chunks before returning their concatenation.
+type streamingRunner struct {
+	chunks []string
+}
+
+func (r streamingRunner) Run(req RunRequest) RunResult {
+	for _, c := range r.chunks {
+		if req.Stream != nil {
+			req.Stream([]byte(c))
+		}
+	}
+	return RunResult{Stdout: []byte(strings.Join(r.chunks, ""))}
+}
+
+func TestExecCmd_StreamsChunksBeforeResult(t *testing.T) {
+	ch := make(chan tea.Msg)
+	go execCmd(RunRequest{}, ch, streamingRunner{chunks: []string{"a\n", "b\n"}}, true)
+
+	var streamed string
+	for {
+		switch msg := (<-ch).(type) {
+		case cmdChunk:
+			streamed += string(msg)
+			continue
+		case cmdData:
+			if streamed != "a\nb\n" || string(msg.stdout) != "a\nb\n" {
+				t.Fatalf("expected the chunks before the result, got %q then %q", streamed, msg.stdout)
+			}
+		}
+		return
+	}
+}
+
+func TestExecCmd_NoChunksWithoutStreaming(t *testing.T) {
+	ch := make(chan tea.Msg)
+	go execCmd(RunRequest{}, ch, streamingRunner{chunks: []string{"a\n"}}, false)
+	if msg, ok := (<-ch).(cmdData); !ok || string(msg.stdout) != "a\n" {
+		t.Fatalf("expected the result only, got %#v", msg)
+	}
+}
+
+func TestStreaming_ShowsPartialOutputUntilTheRunEnds(t *testing.T) {
+	m := newStatusModel(200)
+	m.cfg.Stream = true
+	m.procCmdData(cmdDataWith("previous", 0))
+	m.inProgress = true
+	m.runStart = time.Now().Add(-3 * time.Second)
+
+	model, _ := m.Update(cmdChunk("partial\n"))
+	model, _ = model.(Model).Update(updateStdOut{})
+	m = model.(Model)
+	if got := m.viewport.Content(); got != "partial\n" {
+		t.Fatalf("expected the partial output in the viewport, got %q", got)
+	}
+	if m.cmdRecords != 1 {
+		t.Fatalf("expected no record before the run ends, got %d", m.cmdRecords)
+	}
+	if got := m.statusView(); !strings.Contains(got, "running… 3s") {
+		t.Fatalf("expected the running indicator in the status bar, got %q", got)
+	}
+
+	model, _ = m.Update(cmdDataWith("partial\nfinal\n", 0))
+	model, _ = model.(Model).Update(updateStdOut{})
+	m = model.(Model)
+	if m.partial != nil || m.cmdRecords != 2 || m.viewport.Content() != "partial\nfinal\n" {
+		t.Fatalf("expected the run to be committed, got %d records and %q", m.cmdRecords, m.viewport.Content())
+	}
+	if strings.Contains(m.statusView(), "running…") {
+		t.Fatalf("expected no running indicator once the run ended")
+	}
+}
+
+func TestStreaming_PausedKeepsTheViewedRecord(t *testing.T) {
+	m := newStatusModel(200)
+	m.cfg.Stream = true
+	m.procCmdData(cmdDataWith("previous", 0))
+	m.paused = true
+
+	model, _ := m.Update(cmdChunk("partial\n"))
+	model, _ = model.(Model).Update(updateStdOut{})
+	if got := model.(Model).viewport.Content(); got != "previous" {
+		t.Fatalf("expected the viewed record to stay, got %q", got)
+	}
+}
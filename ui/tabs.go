@@ -0,0 +1,115 @@
+package ui
+
+import (
+	"fmt"
+
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	tabBarHeight = 1
+	tabNameWidth = 24 // commands are cut to this width in the tab bar
+)
+
+// tabBar renders a tab per pane: its number, its command, the exit code of
+// its last run and ● when its output changed since it was last displayed.
+func (m Multi) tabBar() string {
+	var bar string
+	for i := range m.panes {
+		bar += m.tabLabel(i)
+	}
+	return ansi.Truncate(bar, m.width, "…")
+}
+
+func (m Multi) tabLabel(i int) string {
+	p := m.panes[i]
+	t := p.cfg.Theme
+	style := lipgloss.NewStyle().Background(t.StatusBgColor).Foreground(t.StatusFgColor)
+	if i == m.focus {
+		bg := t.StatusRunColor
+		if p.paused {
+			bg = t.StatusStopColor
+		}
+		style = style.Background(bg).Foreground(t.StatusModeFgColor)
+	}
+
+	label := style.Render(fmt.Sprintf(" %d %s ", i+1, ansi.Truncate(p.cfg.Cmd, tabNameWidth, "…")))
+	if !p.firstRun {
+		exit := style
+		if i != m.focus {
+			exit = exit.Foreground(t.StatusRunColor)
+			if p.lastExit != 0 {
+				exit = exit.Foreground(t.StatusStopColor)
+			}
+		}
+		label += exit.Render(fmt.Sprintf("[%d] ", p.lastExit))
+	}
+	if m.unread(i) {
+		label += style.Foreground(t.StatusOptionColor).Render("● ")
+	}
+	return label
+}
+
+// tabAt returns the tab displayed at column x of the tab bar, or -1.
+func (m Multi) tabAt(x int) int {
+	for i := range m.panes {
+		w := lipgloss.Width(m.tabLabel(i))
+		if x < w {
+			return i
+		}
+		x -= w
+	}
+	return -1
+}
+
+// unread returns whether the output of pane i changed since it was last
+// displayed.
+func (m Multi) unread(i int) bool {
+	return m.panes[i].changes > m.seen[i]
+}
+
+// openAddPrompt shows the prompt for a new command in place of the last
+// line of the screen.
+func (m *Multi) openAddPrompt() tea.Cmd {
+	m.adding = true
+	m.prompt = textinput.New()
+	m.prompt.Prompt = "new command: "
+	m.prompt.Placeholder = "[INTERVAL:]COMMAND"
+	m.prompt.SetWidth(max(m.width-len(m.prompt.Prompt)-1, 1))
+	return m.prompt.Focus()
+}
+
+// updateAddPrompt handles key presses while the prompt for a new command is
+// open: enter watches the command in a new pane, esc cancels.
+func (m Multi) updateAddPrompt(msg tea.KeyPressMsg) (Multi, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		m.adding = false
+		return m, nil
+	case "enter":
+		m.adding = false
+		return m, m.addPane(m.prompt.Value())
+	}
+	var cmd tea.Cmd
+	m.prompt, cmd = m.prompt.Update(msg)
+	return m, cmd
+}
+
+// addPane watches the command described by spec, as [INTERVAL:]COMMAND, in
+// a new pane with the focus. Invalid specifications are ignored.
+func (m *Multi) addPane(spec string) tea.Cmd {
+	interval, command, err := ParseCommandSpec(spec, m.base.Interval)
+	if err != nil {
+		return nil
+	}
+	cfg := m.base
+	cfg.Cmd, cfg.Exec, cfg.Interval = command, nil, interval
+	m.panes = append(m.panes, NewModel(cfg))
+	m.seen = append(m.seen, 0)
+	i := len(m.panes) - 1
+	m.setFocus(i)
+	return tea.Batch(tagCmd(i, m.panes[i].Init()), m.resize())
+}
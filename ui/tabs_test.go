@@ -0,0 +1,90 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestTabs_OnlyTheFocusedTabIsDisplayed(t *testing.T) {
+	m := newTestMulti(LayoutTabs, "uptime", "df -h")
+	if m.panes[0].height != 23 || m.panes[1].height != 23 {
+		t.Fatalf("expected every tab below the tab bar, got %d", m.panes[0].height)
+	}
+	lines := strings.Split(m.View().Content, "\n")
+	if len(lines) != 24 {
+		t.Fatalf("expected 24 lines, got %d", len(lines))
+	}
+	bar := ansi.Strip(lines[0])
+	if !strings.Contains(bar, " 1 uptime ") || !strings.Contains(bar, " 2 df -h ") {
+		t.Fatalf("expected a tab per command, got %q", bar)
+	}
+	if !strings.Contains(lines[1], "uptime") || strings.Contains(lines[1], "df -h") {
+		t.Fatalf("expected the status bar of the first tab, got %q", ansi.Strip(lines[1]))
+	}
+	if got := m.paneAt(3, 10); got != 0 {
+		t.Fatalf("expected the focused tab under the pointer, got %d", got)
+	}
+}
+
+func TestTabs_ExitCodeAndUnreadMarker(t *testing.T) {
+	m := newTestMulti(LayoutTabs, "a", "b")
+	for _, out := range []string{"first", "second"} {
+		model, _ := m.Update(paneMsg{pane: 1, msg: cmdDataWith(out, 2)})
+		m = model.(Multi)
+	}
+	if label := ansi.Strip(m.tabLabel(1)); label != " 2 b [2] ● " {
+		t.Fatalf("expected the exit code and the unread marker, got %q", label)
+	}
+
+	model, _ := m.Update(tea.KeyPressMsg{Code: tea.KeyTab})
+	m = model.(Multi)
+	if label := ansi.Strip(m.tabLabel(1)); label != " 2 b [2] " {
+		t.Fatalf("expected the marker to be cleared once displayed, got %q", label)
+	}
+}
+
+func TestTabs_ClickOnTheTabBar(t *testing.T) {
+	m := newTestMulti(LayoutTabs, "a", "b")
+	x := len(ansi.Strip(m.tabLabel(0))) + 1
+	model, _ := m.Update(tea.MouseClickMsg{X: x, Y: 0, Button: tea.MouseLeft})
+	if model.(Multi).focus != 1 {
+		t.Fatalf("expected the click to select the second tab")
+	}
+}
+
+func TestTabs_AddCommand(t *testing.T) {
+	m := newTestMulti(LayoutTabs, "a")
+	model, _ := m.Update(tea.KeyPressMsg{Code: 't', Text: "t"})
+	m = model.(Multi)
+	if !m.adding {
+		t.Fatalf("expected t to open the prompt")
+	}
+	for _, r := range "5:uptime" {
+		model, _ = m.Update(tea.KeyPressMsg{Code: r, Text: string(r)})
+		m = model.(Multi)
+	}
+	if got := ansi.Strip(strings.Split(m.View().Content, "\n")[23]); !strings.HasPrefix(got, "new command: 5:uptime") {
+		t.Fatalf("expected the prompt on the last line, got %q", got)
+	}
+
+	model, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	m = model.(Multi)
+	if m.adding || len(m.panes) != 2 || m.focus != 1 {
+		t.Fatalf("expected a new focused tab, got %d tabs, focus %d", len(m.panes), m.focus)
+	}
+	if p := m.panes[1]; p.cfg.Cmd != "uptime" || p.cfg.Interval.Seconds() != 5 || p.height != 23 {
+		t.Fatalf("expected the new tab to watch uptime every 5s, got %q every %v", p.cfg.Cmd, p.cfg.Interval)
+	}
+}
+
+func TestTabs_AddCommandCancelled(t *testing.T) {
+	m := newTestMulti(LayoutTabs, "a")
+	model, _ := m.Update(tea.KeyPressMsg{Code: 't', Text: "t"})
+	model, _ = model.(Multi).Update(tea.KeyPressMsg{Code: tea.KeyEscape})
+	if m = model.(Multi); m.adding || len(m.panes) != 1 {
+		t.Fatalf("expected esc to close the prompt without adding a tab")
+	}
+}
@@ -1,7 +1,9 @@
 package theme
 
 import (
+	"fmt"
 	"image/color"
+	"strings"
 
 	"charm.land/lipgloss/v2"
 )
@@ -9,14 +11,18 @@ import (
 // SasqTheme defines the color palette used throughout sasqwatch's UI.
 // Use DefaultTheme to get the standard terminal-color theme.
 type SasqTheme struct {
-	StatusRunColor    color.Color // running (▶) state indicator
-	StatusStopColor   color.Color // paused (■) state indicator
-	StatusOptionColor color.Color // counters and mode indicators
-	StatusBgColor     color.Color // status bar background
-	StatusFgColor     color.Color // status bar foreground
-	StatusModeFgColor color.Color // foreground for the run/stop mode block
-	DiffColor         color.Color // background highlight for diff insertions
-	OptionSeparator   string      // separates mode tokens in the status bar
+	StatusRunColor    color.Color   // running (▶) state indicator
+	StatusStopColor   color.Color   // paused (■) state indicator
+	StatusOptionColor color.Color   // counters and mode indicators
+	StatusBgColor     color.Color   // status bar background
+	StatusFgColor     color.Color   // status bar foreground
+	StatusModeFgColor color.Color   // foreground for the run/stop mode block
+	DiffColor         color.Color   // background highlight for diff insertions
+	HeatColors        []color.Color // heatmap gradient, from most to least recently changed
+	MatchColor        color.Color   // background highlight for search matches
+	CurrentMatchColor color.Color   // background highlight for the match last jumped to
+	GutterColor       color.Color   // line numbers in the gutter
+	OptionSeparator   string        // separates mode tokens in the status bar
 }
 
 // DefaultTheme returns the default sasqwatch color theme using standard ANSI terminal colors.
@@ -29,6 +35,54 @@ func DefaultTheme() SasqTheme {
 		StatusFgColor:     lipgloss.Color("7"), // white
 		StatusModeFgColor: lipgloss.Color("0"), // black — readable on green/red backgrounds
 		DiffColor:         lipgloss.Color("1"), // red
+		HeatColors: []color.Color{
+			lipgloss.Color("196"), // red
+			lipgloss.Color("202"),
+			lipgloss.Color("208"), // orange
+			lipgloss.Color("214"),
+			lipgloss.Color("220"), // yellow
+			lipgloss.Color("58"),  // dim olive
+		},
+		MatchColor:        lipgloss.Color("3"), // yellow
+		CurrentMatchColor: lipgloss.Color("6"), // cyan
+		GutterColor:       lipgloss.Color("8"), // bright black
 		OptionSeparator:   "| ",
 	}
 }
+
+// With returns t with the colors named in overrides replaced, as set in the
+// configuration file. Names are the fields without the Color suffix in
+// camel case, e.g. statusRun; colors are ANSI numbers or #rrggbb, and heat
+// is a comma separated list of colors. optionSeparator sets the separator.
+func (t SasqTheme) With(overrides map[string]string) (SasqTheme, error) {
+	colors := map[string]*color.Color{
+		"statusRun":    &t.StatusRunColor,
+		"statusStop":   &t.StatusStopColor,
+		"statusOption": &t.StatusOptionColor,
+		"statusBg":     &t.StatusBgColor,
+		"statusFg":     &t.StatusFgColor,
+		"statusModeFg": &t.StatusModeFgColor,
+		"diff":         &t.DiffColor,
+		"match":        &t.MatchColor,
+		"currentMatch": &t.CurrentMatchColor,
+		"gutter":       &t.GutterColor,
+	}
+	for name, value := range overrides {
+		switch name {
+		case "optionSeparator":
+			t.OptionSeparator = value
+		case "heat":
+			t.HeatColors = nil
+			for _, c := range strings.Split(value, ",") {
+				t.HeatColors = append(t.HeatColors, lipgloss.Color(strings.TrimSpace(c)))
+			}
+		default:
+			c, ok := colors[name]
+			if !ok {
+				return t, fmt.Errorf("unknown theme color %q", name)
+			}
+			*c = lipgloss.Color(value)
+		}
+	}
+	return t, nil
+}
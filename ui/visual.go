@@ -0,0 +1,67 @@
+package ui
+
+import (
+	tea "charm.land/bubbletea/v2"
+	"github.com/fabio42/sasqwatch/viewport"
+)
+
+// openVisual enters visual mode with the cursor on the first line of the
+// scrolling part of the viewport.
+func (m *Model) openVisual(mode viewport.SelectMode) {
+	m.visual = true
+	m.visualMode = mode
+	m.visualCursor = m.viewport.PosAt(0, m.viewport.FrozenRows())
+	m.visualAnchor = m.visualCursor
+	m.viewport.Select(m.visualAnchor, m.visualCursor, mode)
+}
+
+// closeVisual leaves visual mode and clears the selection.
+func (m *Model) closeVisual() {
+	m.visual = false
+	m.viewport.ClearSelection()
+}
+
+// updateVisual handles key presses in visual mode, like vim: the cursor
+// extends the selection from where visual mode was entered, y or enter yanks
+// the selection and esc leaves. v and ctrl+v switch between line and block
+// selection, or leave when pressed again in their own mode.
+func (m Model) updateVisual(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
+	cur := m.visualCursor
+	switch msg.String() {
+	case "esc", "q", "ctrl+c":
+		m.closeVisual()
+		return m, nil
+	case "y", "enter":
+		text := m.viewport.SelectedText()
+		m.closeVisual()
+		return m, m.copyText(text)
+	case "v", "ctrl+v":
+		mode := viewport.SelectLine
+		if msg.String() == "ctrl+v" {
+			mode = viewport.SelectBlock
+		}
+		if mode == m.visualMode {
+			m.closeVisual()
+			return m, nil
+		}
+		m.visualMode = mode
+	case "j", "down":
+		cur.Line++
+	case "k", "up":
+		cur.Line--
+	case "pgdown", "ctrl+f":
+		cur.Line += m.viewport.Height
+	case "pgup", "ctrl+b":
+		cur.Line -= m.viewport.Height
+	case "h", "left":
+		cur.Col--
+	case "l", "right":
+		cur.Col++
+	}
+	cur.Line = min(max(cur.Line, 0), m.viewport.TotalLineCount()-1)
+	cur.Col = max(cur.Col, 0)
+	m.visualCursor = cur
+	m.viewport.Select(m.visualAnchor, cur, m.visualMode)
+	m.viewport.ShowLine(cur.Line)
+	return m, nil
+}
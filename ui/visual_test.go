@@ -0,0 +1,54 @@
+package ui
+
+import (
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+func TestVisual_YanksSelectedLines(t *testing.T) {
+	m := newTestModel(5)
+	m.viewport.Height = 5
+	m.viewport.SetContent("alpha\nbravo\ncharlie\ndelta")
+	clip := m.cfg.Clip.(*fakeClipboard)
+
+	var model tea.Model = m
+	for _, k := range []tea.KeyPressMsg{
+		{Code: 'v', Text: "v"},
+		{Code: 'j', Text: "j"},
+		{Code: 'j', Text: "j"},
+		{Code: 'y', Text: "y"},
+	} {
+		model, _ = model.Update(k)
+	}
+	m = model.(Model)
+	if clip.written != "alpha\nbravo\ncharlie" {
+		t.Fatalf("unexpected yanked text %q", clip.written)
+	}
+	if m.visual || !m.copyCb {
+		t.Fatalf("expected visual mode to end with a copy notification")
+	}
+}
+
+func TestVisual_BlockSelectsColumns(t *testing.T) {
+	m := newTestModel(5)
+	m.viewport.Height = 5
+	m.viewport.SetContent("abcd\nefgh\nij")
+	clip := m.cfg.Clip.(*fakeClipboard)
+
+	var model tea.Model = m
+	for _, k := range []tea.KeyPressMsg{
+		{Code: 'v', Mod: tea.ModCtrl},
+		{Code: 'l', Text: "l"},
+		{Code: 'l', Text: "l"},
+		{Code: 'j', Text: "j"},
+		{Code: 'j', Text: "j"},
+		{Code: 'h', Text: "h"},
+		{Code: tea.KeyEnter},
+	} {
+		model, _ = model.Update(k)
+	}
+	if clip.written != "ab\nef\nij" {
+		t.Fatalf("unexpected yanked block %q", clip.written)
+	}
+}
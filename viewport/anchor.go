@@ -0,0 +1,76 @@
+package viewport
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// ScrollMode controls what happens to the scroll position when the content
+// is replaced with SetContent.
+type ScrollMode int
+
+const (
+	// ScrollClamp keeps the offset as is, only clamping it to the new content.
+	ScrollClamp ScrollMode = iota
+	// ScrollAnchor keeps the content line shown at the top of the viewport in
+	// place, even when lines are inserted or removed above it.
+	ScrollAnchor
+	// ScrollFollow sticks to the bottom of the content, like tail -f, as long
+	// as the viewport was at the bottom before the refresh.
+	ScrollFollow
+)
+
+// anchor remembers the content line at the top of the viewport.
+type anchor struct {
+	lines []string // content the anchor refers to
+	line  int      // content line index
+	row   int      // wrapped row of the line shown at the top
+}
+
+func (m Model) currentAnchor() anchor {
+	top := m.TopLine()
+	return anchor{lines: m.lines, line: top, row: max(0, m.YOffset-m.rowOf(top))}
+}
+
+// alignAnchor returns the index in the current content of the anchor line.
+// Both versions of the content are aligned with a line diff (ignoring
+// escape sequences, so highlight changes do not matter): an unchanged line
+// keeps its identity however many lines were inserted or removed above it,
+// and a removed line maps to where it used to be.
+func (m Model) alignAnchor(a anchor) int {
+	dmp := diffmatchpatch.New()
+	before, after, lines := dmp.DiffLinesToChars(stripLines(a.lines), stripLines(m.lines))
+	oldIdx, newIdx := 0, 0
+	for _, d := range dmp.DiffCharsToLines(dmp.DiffMain(before, after, false), lines) {
+		n := strings.Count(d.Text, "\n")
+		if !strings.HasSuffix(d.Text, "\n") {
+			n++
+		}
+		switch d.Type {
+		case diffmatchpatch.DiffEqual:
+			if a.line < oldIdx+n {
+				return newIdx + a.line - oldIdx
+			}
+			oldIdx += n
+			newIdx += n
+		case diffmatchpatch.DiffDelete:
+			if a.line < oldIdx+n {
+				return clamp(newIdx, 0, len(m.lines)-1)
+			}
+			oldIdx += n
+		case diffmatchpatch.DiffInsert:
+			newIdx += n
+		}
+	}
+	return clamp(a.line, 0, len(m.lines)-1)
+}
+
+func stripLines(lines []string) string {
+	stripped := make([]string, len(lines))
+	for i, l := range lines {
+		stripped[i] = ansi.Strip(l)
+	}
+	return strings.Join(stripped, "\n")
+}
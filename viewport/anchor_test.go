@@ -0,0 +1,42 @@
+package viewport
+
+import "testing"
+
+func TestSetContent_Anchor_KeepsLineInViewWhenLinesInsertedAbove(t *testing.T) {
+	m := newTestViewport(20, 2, "a\nb\nc\nd\ne")
+	m.ScrollMode = ScrollAnchor
+	m.SetYOffset(2) // "c" at the top
+
+	m.SetContent("new1\nnew2\na\nb\nc\nd\ne")
+	if m.YOffset != 4 {
+		t.Fatalf("expected 'c' to stay at the top (YOffset=4), got %d", m.YOffset)
+	}
+}
+
+func TestSetContent_Anchor_RemovedLineMapsToItsPosition(t *testing.T) {
+	m := newTestViewport(20, 2, "a\nb\nc\nd\ne")
+	m.ScrollMode = ScrollAnchor
+	m.SetYOffset(2)
+
+	m.SetContent("a\nd\ne\nf")
+	if m.YOffset != 1 {
+		t.Fatalf("expected removed anchor to map to line 1, got %d", m.YOffset)
+	}
+}
+
+func TestSetContent_Follow_SticksToBottomOnlyWhenAtBottom(t *testing.T) {
+	m := newTestViewport(20, 2, "a\nb\nc")
+	m.ScrollMode = ScrollFollow
+	m.GotoBottom()
+
+	m.SetContent("a\nb\nc\nd\ne")
+	if !m.AtBottom() {
+		t.Fatalf("expected to follow the bottom, YOffset=%d", m.YOffset)
+	}
+
+	m.SetYOffset(0)
+	m.SetContent("a\nb\nc\nd\ne\nf")
+	if m.YOffset != 0 {
+		t.Fatalf("expected scroll position to be kept once scrolled up, got %d", m.YOffset)
+	}
+}
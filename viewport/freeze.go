@@ -0,0 +1,66 @@
+package viewport
+
+import "github.com/charmbracelet/x/ansi"
+
+// SetFrozen freezes the first rows lines of the content so they stay visible
+// at the top while scrolling vertically, and the first cols columns so they
+// stay visible while scrolling horizontally. Zero disables either.
+func (m *Model) SetFrozen(rows, cols int) {
+	top := m.TopLine()
+	m.frozenRows = max(0, rows)
+	m.frozenCols = max(0, cols)
+	m.rows = nil
+	m.syncRows()
+	m.SetYOffset(m.rowOf(top))
+}
+
+// FrozenRows returns the number of frozen header lines.
+func (m Model) FrozenRows() int {
+	return m.frozenRows
+}
+
+// FrozenCols returns the number of frozen columns.
+func (m Model) FrozenCols() int {
+	return m.frozenCols
+}
+
+// headerCount returns the number of frozen lines actually displayed.
+func (m Model) headerCount() int {
+	return min(m.frozenRows, len(m.lines), max(m.Height, 0))
+}
+
+// bodyHeight returns the number of rows left for the scrolling part of the
+// content once the frozen header is displayed.
+func (m Model) bodyHeight() int {
+	return max(0, m.Height-m.headerCount())
+}
+
+// visibleRows returns the frozen header rows followed by the visible rows of
+// the scrolling body.
+func (m Model) visibleRows() []row {
+	rows := m.layout()
+	visible := make([]row, 0, m.Height)
+	for i := 0; i < m.headerCount(); i++ {
+		visible = append(visible, row{line: i, text: m.lines[i]})
+	}
+	if len(rows) > 0 {
+		top := max(0, m.YOffset)
+		bottom := clamp(m.YOffset+m.bodyHeight(), top, len(rows))
+		visible = append(visible, rows[top:bottom]...)
+	}
+	return visible
+}
+
+// hscroll applies the horizontal scroll to line, keeping the frozen columns.
+func (m Model) hscroll(line string) string {
+	if m.indent == 0 || m.wrap {
+		return line
+	}
+	if m.frozenCols == 0 {
+		return ansi.TruncateLeft(line, m.indent, "")
+	}
+	if ansi.StringWidth(line) <= m.frozenCols {
+		return line
+	}
+	return ansi.Truncate(line, m.frozenCols, "") + ansi.ResetStyle + ansi.TruncateLeft(line, m.frozenCols+m.indent, "")
+}
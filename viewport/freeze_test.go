@@ -0,0 +1,36 @@
+package viewport
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestSetFrozen_HeaderStaysWhileScrolling(t *testing.T) {
+	m := newTestViewport(20, 3, "NAME\na\nb\nc\nd")
+	m.SetFrozen(1, 0)
+
+	m.GotoBottom()
+	lines := m.visibleLines()
+	if len(lines) != 3 || lines[0] != "NAME" || lines[1] != "c" || lines[2] != "d" {
+		t.Fatalf("expected header followed by the last body rows, got %q", lines)
+	}
+	if m.YOffset != 2 {
+		t.Fatalf("expected body offset 2, got %d", m.YOffset)
+	}
+}
+
+func TestSetFrozen_ColumnsStayWhileScrollingRight(t *testing.T) {
+	m := newTestViewport(20, 2, "NAME  0123456789\npod-a abcdefghij")
+	m.SetFrozen(0, 6)
+	m.MoveRight()
+
+	lines := m.visibleLines()
+	if got := ansi.Strip(lines[1]); got != "pod-a fghij" {
+		t.Fatalf("expected frozen name column, got %q", got)
+	}
+	if !strings.HasPrefix(ansi.Strip(lines[0]), "NAME  5") {
+		t.Fatalf("expected header to scroll the same way, got %q", ansi.Strip(lines[0]))
+	}
+}
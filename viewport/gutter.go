@@ -0,0 +1,49 @@
+package viewport
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SetLineNumbers shows or hides the line number gutter. Numbers refer to
+// content lines: they stay the same while scrolling horizontally, and
+// continuation rows of wrapped lines get a blank gutter.
+func (m *Model) SetLineNumbers(show bool) {
+	m.lineNumbers = show
+	m.rows = nil
+	m.syncRows()
+	m.SetYOffset(m.YOffset)
+}
+
+// LineNumbers returns whether the line number gutter is shown.
+func (m Model) LineNumbers() bool {
+	return m.lineNumbers
+}
+
+// gutterWidth returns the number of columns taken by the gutter, including
+// the separating space.
+func (m Model) gutterWidth() int {
+	if !m.lineNumbers {
+		return 0
+	}
+	return len(strconv.Itoa(len(m.lines))) + 1
+}
+
+// withGutter prefixes the visible lines with the gutter of their rows.
+func (m Model) withGutter(lines []string) []string {
+	if !m.lineNumbers || len(lines) == 0 {
+		return lines
+	}
+	width := m.gutterWidth() - 1
+	rows := m.visibleRows()
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		num := strings.Repeat(" ", width)
+		if rows[i].seg == 0 {
+			num = fmt.Sprintf("%*d", width, rows[i].line+1)
+		}
+		out[i] = m.GutterStyle.Render(num) + " " + line
+	}
+	return out
+}
@@ -0,0 +1,35 @@
+package viewport
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestGutter_NumbersStableAcrossWrapAndScroll(t *testing.T) {
+	lines := make([]string, 10)
+	for i := range lines {
+		lines[i] = "x"
+	}
+	lines[0] = "abcdefghij"
+	m := newTestViewport(8, 3, strings.Join(lines, "\n"))
+	m.SetLineNumbers(true)
+	m.SetWrap(true)
+
+	view := strings.Split(ansi.Strip(m.View()), "\n")
+	for i := range view {
+		view[i] = strings.TrimRight(view[i], " ")
+	}
+	// gutter is 3 columns ("10" + space), leaving 5 for the content.
+	if view[0] != " 1 abcde" || view[1] != "   fghij" || view[2] != " 2 x" {
+		t.Fatalf("unexpected rows %q", view)
+	}
+
+	m.SetWrap(false)
+	m.MoveRight()
+	view = strings.Split(ansi.Strip(m.View()), "\n")
+	if !strings.HasPrefix(view[0], " 1 ") {
+		t.Fatalf("expected gutter to stay while scrolling horizontally, got %q", view[0])
+	}
+}
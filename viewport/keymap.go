@@ -17,6 +17,8 @@ type KeyMap struct {
 	Up           key.Binding
 	Left         key.Binding
 	Right        key.Binding
+	Top          key.Binding
+	Bottom       key.Binding
 }
 
 // DefaultKeyMap returns a set of pager-like default keybindings.
@@ -54,5 +56,13 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("right", "l"),
 			key.WithHelp("→/l", "move right"),
 		),
+		Top: key.NewBinding(
+			key.WithKeys("home", "g"),
+			key.WithHelp("g/home", "go to top"),
+		),
+		Bottom: key.NewBinding(
+			key.WithKeys("end", "G"),
+			key.WithHelp("G/end", "go to bottom"),
+		),
 	}
 }
@@ -0,0 +1,188 @@
+package viewport
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/fabio42/sasqwatch/sgr"
+)
+
+// match is a search hit, in runes of the line once escape sequences are
+// stripped.
+type match struct {
+	line, start, end int
+}
+
+// SetSearch sets the pattern highlighted in the content. Matches are
+// recomputed on every SetContent so the search survives content refreshes.
+// A nil pattern clears the search.
+func (m *Model) SetSearch(re *regexp.Regexp) {
+	m.search = re
+	m.matchIdx = -1
+	m.findMatches()
+}
+
+// Search returns the active search pattern, or nil.
+func (m Model) Search() *regexp.Regexp {
+	return m.search
+}
+
+// MatchCount returns the number of matches of the active search.
+func (m Model) MatchCount() int {
+	return len(m.matches)
+}
+
+// CurrentMatch returns the index of the match last jumped to, or -1.
+func (m Model) CurrentMatch() int {
+	return m.matchIdx
+}
+
+// SearchNext scrolls to the match following the current one, or to the first
+// match at or below the top of the viewport, wrapping around at the end.
+// It returns false when there is nothing to jump to.
+func (m *Model) SearchNext() bool {
+	if len(m.matches) == 0 {
+		return false
+	}
+	if m.matchIdx >= 0 {
+		m.matchIdx = (m.matchIdx + 1) % len(m.matches)
+	} else {
+		m.matchIdx = 0
+		for i, mt := range m.matches {
+			if mt.line >= m.TopLine() {
+				m.matchIdx = i
+				break
+			}
+		}
+	}
+	m.showMatch()
+	return true
+}
+
+// SearchPrev scrolls to the match preceding the current one, or to the last
+// match at or above the top of the viewport, wrapping around at the start.
+// It returns false when there is nothing to jump to.
+func (m *Model) SearchPrev() bool {
+	if len(m.matches) == 0 {
+		return false
+	}
+	if m.matchIdx >= 0 {
+		m.matchIdx = (m.matchIdx - 1 + len(m.matches)) % len(m.matches)
+	} else {
+		m.matchIdx = len(m.matches) - 1
+		for i := len(m.matches) - 1; i >= 0; i-- {
+			if m.matches[i].line <= m.TopLine() {
+				m.matchIdx = i
+				break
+			}
+		}
+	}
+	m.showMatch()
+	return true
+}
+
+// showMatch scrolls just enough for the current match to be visible.
+func (m *Model) showMatch() {
+	mt := m.matches[m.matchIdx]
+	m.ShowLine(mt.line)
+	if w := m.contentWidth(); !m.wrap && (mt.start < m.indent || mt.end > m.indent+w) {
+		m.indent = max(0, mt.start-w/4)
+	}
+}
+
+func (m *Model) findMatches() {
+	m.matches = nil
+	if m.search == nil {
+		m.matchIdx = -1
+		return
+	}
+	for i, line := range m.lines {
+		text := ansi.Strip(line)
+		for _, loc := range m.search.FindAllStringIndex(text, -1) {
+			if loc[0] == loc[1] {
+				continue
+			}
+			start := utf8.RuneCountInString(text[:loc[0]])
+			m.matches = append(m.matches, match{
+				line:  i,
+				start: start,
+				end:   start + utf8.RuneCountInString(text[loc[0]:loc[1]]),
+			})
+		}
+	}
+	if m.matchIdx >= len(m.matches) {
+		m.matchIdx = -1
+	}
+}
+
+// highlightLine returns content line n with the match and selection styles
+// applied.
+func (m Model) highlightLine(n int) string {
+	first := sort.Search(len(m.matches), func(i int) bool { return m.matches[i].line >= n })
+	var spans []span
+	for i := first; i < len(m.matches) && m.matches[i].line == n; i++ {
+		style := m.MatchStyle.String()
+		if i == m.matchIdx {
+			style = m.CurrentMatchStyle.String()
+		}
+		spans = append(spans, span{start: m.matches[i].start, end: m.matches[i].end, style: style})
+	}
+	spans = m.selectionSpans(n, spans)
+	if len(spans) == 0 {
+		return m.lines[n]
+	}
+	return highlight(m.lines[n], spans)
+}
+
+// span is a range of visible runes to render with an SGR style.
+type span struct {
+	start, end int
+	style      string
+}
+
+// highlight renders the visible runes covered by spans with their style while
+// keeping the escape sequences of line. spans must be sorted and disjoint.
+func highlight(line string, spans []span) string {
+	var out strings.Builder
+	var state sgr.State // SGR state of the line itself
+	var cur *span
+	v := 0
+	for i := 0; i < len(line); {
+		if cur != nil && v >= cur.end {
+			out.WriteString(ansi.ResetStyle + state.String())
+			cur = nil
+		}
+		if cur == nil && len(spans) > 0 && v >= spans[0].start {
+			cur = &spans[0]
+			spans = spans[1:]
+			out.WriteString(cur.style)
+		}
+
+		if line[i] == ansi.ESC {
+			n := sgr.SeqLen(line[i:])
+			seq := line[i : i+n]
+			out.WriteString(seq)
+			if params, ok := sgr.Params(seq); ok {
+				state = state.ApplyParams(params)
+				if cur != nil {
+					out.WriteString(cur.style)
+				}
+			}
+			i += n
+			continue
+		}
+
+		_, size := utf8.DecodeRuneInString(line[i:])
+		out.WriteString(line[i : i+size])
+		i += size
+		v++
+	}
+	if cur != nil {
+		out.WriteString(ansi.ResetStyle + state.String())
+	}
+	return out.String()
+}
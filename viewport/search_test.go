@@ -0,0 +1,79 @@
+package viewport
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestSearchNext_WrapsAround(t *testing.T) {
+	m := newTestViewport(20, 2, "pod-a\nx\ny\npod-b\nz")
+	m.SetSearch(regexp.MustCompile("pod"))
+	if m.MatchCount() != 2 {
+		t.Fatalf("expected 2 matches, got %d", m.MatchCount())
+	}
+
+	m.SearchNext()
+	m.SearchNext()
+	if m.CurrentMatch() != 1 || m.YOffset != 3 {
+		t.Fatalf("expected match 1 at line 3, got match %d at line %d", m.CurrentMatch(), m.YOffset)
+	}
+	m.SearchNext()
+	if m.CurrentMatch() != 0 || m.YOffset != 0 {
+		t.Fatalf("expected wrap to match 0 at line 0, got match %d at line %d", m.CurrentMatch(), m.YOffset)
+	}
+	m.SearchPrev()
+	if m.CurrentMatch() != 1 {
+		t.Fatalf("expected backward wrap to match 1, got %d", m.CurrentMatch())
+	}
+}
+
+func TestSearchPrev_WrapModeStartsAboveTopLine(t *testing.T) {
+	m := newTestViewport(4, 2, "pod-aaaaaaaaaaaa\npod-b\nx\npod-c\ny")
+	m.SetWrap(true)
+	m.SetSearch(regexp.MustCompile("pod"))
+	m.GotoLine(2)
+	if m.TopLine() != 2 || m.YOffset != 6 {
+		t.Fatalf("expected line 2 on wrapped row 6, got line %d at row %d", m.TopLine(), m.YOffset)
+	}
+
+	m.SearchPrev()
+	if m.CurrentMatch() != 1 {
+		t.Fatalf("expected the match on line 1 above the top line, got match %d", m.CurrentMatch())
+	}
+}
+
+func TestSearch_PersistsAcrossSetContent(t *testing.T) {
+	m := newTestViewport(20, 5, "alpha")
+	m.SetSearch(regexp.MustCompile("beta"))
+	if m.MatchCount() != 0 {
+		t.Fatalf("expected no match, got %d", m.MatchCount())
+	}
+	m.SetContent("alpha\nbeta\nbeta")
+	if m.MatchCount() != 2 {
+		t.Fatalf("expected matches to be recomputed on new content, got %d", m.MatchCount())
+	}
+}
+
+func TestSearch_HighlightsVisibleLines(t *testing.T) {
+	m := newTestViewport(20, 5, "a \x1b[32mpod\x1b[0m b")
+	m.SetSearch(regexp.MustCompile("od"))
+
+	line := m.visibleLines()[0]
+	if ansi.Strip(line) != "a pod b" {
+		t.Fatalf("highlight must not alter visible text, got %q", ansi.Strip(line))
+	}
+	if !strings.Contains(line, "p"+m.MatchStyle.String()+"od") {
+		t.Fatalf("expected match to be highlighted, got %q", line)
+	}
+}
+
+func TestHighlight_RestoresLineStyleAfterSpan(t *testing.T) {
+	got := highlight("\x1b[31mabc", []span{{start: 1, end: 2, style: "<hl>"}})
+	want := "\x1b[31ma<hl>b" + ansi.ResetStyle + "\x1b[31mc"
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
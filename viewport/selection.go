@@ -0,0 +1,156 @@
+package viewport
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/mattn/go-runewidth"
+)
+
+// Pos is a position in the content: a line and a column counted in visible
+// runes, escape sequences excluded.
+type Pos struct {
+	Line, Col int
+}
+
+// SelectMode is the shape of a selection.
+type SelectMode int
+
+const (
+	// SelectChar selects every rune between the two ends, like a terminal
+	// selection.
+	SelectChar SelectMode = iota
+	// SelectLine selects whole lines.
+	SelectLine
+	// SelectBlock selects the same range of columns on every line, between
+	// the columns of the two ends.
+	SelectBlock
+)
+
+type selection struct {
+	from, to Pos
+	mode     SelectMode
+	active   bool
+}
+
+// PosAt returns the content position displayed at column x of row y of the
+// viewport, accounting for the gutter, the horizontal scroll and wrapping.
+// Rows past the content map to its last row and columns past the end of a
+// line to the end of that line.
+func (m Model) PosAt(x, y int) Pos {
+	rows := m.visibleRows()
+	if len(rows) == 0 {
+		return Pos{}
+	}
+	r := rows[clamp(y, 0, len(rows)-1)]
+	col := max(0, x-m.gutterWidth())
+	if !m.wrap && m.indent > 0 && col >= m.frozenCols {
+		col += m.indent
+	}
+
+	offset := 0
+	if r.seg > 0 {
+		for _, seg := range wrapLine(ansi.Strip(m.lines[r.line]), m.contentWidth())[:r.seg] {
+			offset += len([]rune(seg))
+		}
+	}
+	w := 0
+	text := []rune(ansi.Strip(r.text))
+	for i, c := range text {
+		w += runewidth.RuneWidth(c)
+		if w > col {
+			return Pos{Line: r.line, Col: offset + i}
+		}
+	}
+	return Pos{Line: r.line, Col: offset + len(text)}
+}
+
+// Select selects the content between from and to, both included, in any
+// order. The selection is highlighted with SelectionStyle.
+func (m *Model) Select(from, to Pos, mode SelectMode) {
+	m.sel = selection{from: from, to: to, mode: mode, active: true}
+}
+
+// ClearSelection removes the selection.
+func (m *Model) ClearSelection() {
+	m.sel = selection{}
+}
+
+// HasSelection returns whether some content is selected.
+func (m Model) HasSelection() bool {
+	return m.sel.active
+}
+
+// SelectedText returns the selected content without escape sequences, lines
+// separated by newlines.
+func (m Model) SelectedText() string {
+	if !m.sel.active {
+		return ""
+	}
+	from, to := m.selBounds()
+	var out []string
+	for n := from.Line; n <= to.Line && n < len(m.lines); n++ {
+		text := []rune(ansi.Strip(m.lines[n]))
+		start, end := m.selSpan(n, len(text))
+		out = append(out, string(text[start:end]))
+	}
+	return strings.Join(out, "\n")
+}
+
+// selBounds returns the ends of the selection in content order.
+func (m Model) selBounds() (from, to Pos) {
+	from, to = m.sel.from, m.sel.to
+	if to.Line < from.Line || (to.Line == from.Line && to.Col < from.Col) {
+		from, to = to, from
+	}
+	return from, to
+}
+
+// selSpan returns the runes of line n, of length runes, covered by the
+// selection. The span is empty when the line is not selected.
+func (m Model) selSpan(n, length int) (start, end int) {
+	from, to := m.selBounds()
+	if !m.sel.active || n < from.Line || n > to.Line {
+		return 0, 0
+	}
+	switch m.sel.mode {
+	case SelectLine:
+		return 0, length
+	case SelectBlock:
+		left, right := min(from.Col, to.Col), max(from.Col, to.Col)
+		return min(left, length), min(right+1, length)
+	}
+	end = length
+	if n == from.Line {
+		start = min(from.Col, length)
+	}
+	if n == to.Line {
+		end = clamp(to.Col+1, start, length)
+	}
+	return start, end
+}
+
+// selectionSpans returns spans with the selection of line n added to spans.
+// Matches overlapping the selection are dropped so spans stay disjoint.
+func (m Model) selectionSpans(n int, spans []span) []span {
+	start, end := m.selSpan(n, len([]rune(ansi.Strip(m.lines[n]))))
+	if start == end {
+		return spans
+	}
+	out := make([]span, 0, len(spans)+1)
+	sel := span{start: start, end: end, style: m.SelectionStyle.String()}
+	placed := false
+	for _, s := range spans {
+		if s.end <= start || s.start >= end {
+			if !placed && s.start >= end {
+				out = append(out, sel)
+				placed = true
+			}
+			out = append(out, s)
+		}
+	}
+	if !placed {
+		out = append(out, sel)
+	}
+	return out
+}
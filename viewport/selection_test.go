@@ -0,0 +1,70 @@
+package viewport
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestPosAt_AccountsForGutterScrollAndWrap(t *testing.T) {
+	m := newTestViewport(6, 3, "abcdefghij\nxy")
+	m.SetLineNumbers(true) // gutter of 2 columns
+	if got := m.PosAt(3, 0); got != (Pos{Line: 0, Col: 1}) {
+		t.Fatalf("expected col 1 past the gutter, got %+v", got)
+	}
+	m.MoveRight()
+	if got := m.PosAt(2, 0); got != (Pos{Line: 0, Col: 5}) {
+		t.Fatalf("expected horizontal scroll to be added, got %+v", got)
+	}
+
+	m.SetWrap(true) // 4 content columns: "abcd", "efgh", "ij", "xy"
+	if got := m.PosAt(3, 1); got != (Pos{Line: 0, Col: 5}) {
+		t.Fatalf("expected col in the second segment, got %+v", got)
+	}
+	if got := m.PosAt(5, 2); got != (Pos{Line: 0, Col: 10}) {
+		t.Fatalf("expected end of line past the last rune, got %+v", got)
+	}
+}
+
+func TestSelectedText_StripsStylesAndSpansLines(t *testing.T) {
+	m := newTestViewport(20, 5, "ab\x1b[31mcd\x1b[0m\nefgh\nijkl")
+	m.Select(Pos{Line: 2, Col: 1}, Pos{Line: 0, Col: 2}, SelectChar)
+	if got := m.SelectedText(); got != "cd\nefgh\nij" {
+		t.Fatalf("unexpected selected text %q", got)
+	}
+	m.ClearSelection()
+	if m.SelectedText() != "" {
+		t.Fatalf("expected no text once the selection is cleared")
+	}
+}
+
+func TestSelection_HighlightedOverMatches(t *testing.T) {
+	m := newTestViewport(20, 5, "abcdef")
+	m.SelectionStyle = ansi.NewStyle().Underline(true)
+	m.Select(Pos{Col: 1}, Pos{Col: 2}, SelectChar)
+
+	line := m.visibleLines()[0]
+	if ansi.Strip(line) != "abcdef" {
+		t.Fatalf("selection must not alter visible text, got %q", ansi.Strip(line))
+	}
+	if !strings.Contains(line, "a"+m.SelectionStyle.String()+"bc"+ansi.ResetStyle) {
+		t.Fatalf("expected selection to be highlighted, got %q", line)
+	}
+	spans := m.selectionSpans(0, []span{{start: 0, end: 1}, {start: 2, end: 4}, {start: 4, end: 5}})
+	if len(spans) != 3 || spans[1].start != 1 || spans[2].start != 4 {
+		t.Fatalf("expected overlapping match dropped and spans sorted, got %+v", spans)
+	}
+}
+
+func TestSelectedText_LineAndBlockModes(t *testing.T) {
+	m := newTestViewport(20, 5, "abcd\nef\nijkl")
+	m.Select(Pos{Line: 0, Col: 2}, Pos{Line: 1, Col: 0}, SelectLine)
+	if got := m.SelectedText(); got != "abcd\nef" {
+		t.Fatalf("expected whole lines, got %q", got)
+	}
+	m.Select(Pos{Line: 2, Col: 1}, Pos{Line: 0, Col: 2}, SelectBlock)
+	if got := m.SelectedText(); got != "bc\nf\njk" {
+		t.Fatalf("expected columns 1-2 of every line, got %q", got)
+	}
+}
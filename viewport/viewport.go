@@ -2,12 +2,13 @@ package viewport
 
 import (
 	"math"
+	"regexp"
 	"strings"
 
 	"charm.land/bubbles/v2/key"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
-	"github.com/mattn/go-runewidth"
+	"github.com/charmbracelet/x/ansi"
 )
 
 const (
@@ -39,16 +40,43 @@ type Model struct {
 	// YOffset is the vertical scroll position.
 	YOffset int
 
+	// ScrollMode controls how YOffset is adjusted when the content changes.
+	ScrollMode ScrollMode
+
 	// Style applies a lipgloss style to the viewport. Realistically, it's most
 	// useful for setting borders, margins and padding.
 	Style lipgloss.Style
 
+	// GutterStyle applies a lipgloss style to the line numbers.
+	GutterStyle lipgloss.Style
+
+	// MatchStyle and CurrentMatchStyle are the SGR styles applied to search
+	// matches and to the match last jumped to.
+	MatchStyle        ansi.Style
+	CurrentMatchStyle ansi.Style
+
+	// SelectionStyle is the SGR style applied to the selected content.
+	SelectionStyle ansi.Style
+
 	// horizontal step represents the step of indent we add with one move left or right.
 	horizontalStep int
 
+	search   *regexp.Regexp
+	matches  []match
+	matchIdx int
+
+	sel selection
+
 	indent      int
 	initialized bool
 	lines       []string
+
+	lineNumbers bool
+	frozenRows  int
+	frozenCols  int
+	wrap        bool
+	rows        []row // cached layout of lines, see layout
+	rowsWidth   int   // width the rows were laid out for
 }
 
 func (m *Model) setInitialValues() {
@@ -57,6 +85,10 @@ func (m *Model) setInitialValues() {
 	m.MouseWheelDelta = 3
 	m.initialized = true
 	m.horizontalStep = defaultHorizontalStep
+	m.MatchStyle = ansi.NewStyle().Reverse(true)
+	m.CurrentMatchStyle = ansi.NewStyle().Reverse(true).Bold()
+	m.SelectionStyle = ansi.NewStyle().Reverse(true)
+	m.matchIdx = -1
 }
 
 // Init exists to satisfy the tea.Model interface for composability purposes.
@@ -82,24 +114,38 @@ func (m Model) PastBottom() bool {
 }
 
 // ScrollPercent returns the amount scrolled as a float between 0 and 1.
+// When wrapping, it is computed over wrapped rows.
 func (m Model) ScrollPercent() float64 {
-	if m.Height >= len(m.lines) {
+	rows := len(m.layout())
+	if m.bodyHeight() >= rows {
 		return 1.0
 	}
 	y := float64(m.YOffset)
-	h := float64(m.Height)
-	t := float64(len(m.lines) - 1)
+	h := float64(m.bodyHeight())
+	t := float64(rows - 1)
 	v := y / (t - h)
 	return math.Max(0.0, math.Min(1.0, v))
 }
 
 // SetContent set the pager's text content. For high performance rendering the
 // Sync command should also be called.
+// The scroll position is then adjusted according to ScrollMode.
 func (m *Model) SetContent(s string) {
+	prev := m.currentAnchor()
+	atBottom := m.AtBottom()
+
 	s = strings.ReplaceAll(s, "\r\n", "\n") // normalize line endings
 	m.lines = strings.Split(s, "\n")
+	m.rows = nil
+	m.syncRows()
+	m.findMatches()
 
-	if m.YOffset > len(m.lines)-1 {
+	switch {
+	case m.ScrollMode == ScrollFollow && atBottom:
+		m.GotoBottom()
+	case m.ScrollMode == ScrollAnchor && len(prev.lines) > 0:
+		m.SetYOffset(m.rowOf(m.alignAnchor(prev)) + prev.row)
+	case m.YOffset > len(m.rows)-1:
 		m.GotoBottom()
 	}
 }
@@ -107,27 +153,30 @@ func (m *Model) SetContent(s string) {
 // maxYOffset returns the maximum possible value of the y-offset based on the
 // viewport's content and set height.
 func (m Model) maxYOffset() int {
-	return max(0, len(m.lines)-m.Height)
+	return max(0, len(m.layout())-m.bodyHeight())
 }
 
 // visibleLines returns the lines that should currently be visible in the
 // viewport.
 func (m Model) visibleLines() (lines []string) {
-	if len(m.lines) > 0 {
-		top := max(0, m.YOffset)
-		bottom := clamp(m.YOffset+m.Height, top, len(m.lines))
-		lines = m.lines[top:bottom]
-	}
-
-	if m.indent > 0 {
-		cutLines := make([]string, len(lines))
-		for i := range lines {
-			cutLines[i] = runewidth.TruncateLeft(lines[i], m.indent, "")
+	rows := m.visibleRows()
+	lines = make([]string, 0, len(rows))
+	var segs []string
+	for i, r := range rows {
+		text := r.text
+		if len(m.matches) > 0 || m.sel.active {
+			// Highlight the whole line so matches spanning wrapped rows are
+			// styled consistently, then pick the row's segment.
+			if i == 0 || r.seg == 0 {
+				segs = []string{m.highlightLine(r.line)}
+				if m.wrap && r.line >= m.headerCount() {
+					segs = wrapLine(segs[0], m.contentWidth())
+				}
+			}
+			text = segs[min(r.seg, len(segs)-1)]
 		}
-
-		return cutLines
+		lines = append(lines, m.hscroll(text))
 	}
-
 	return lines
 }
 
@@ -143,7 +192,7 @@ func (m *Model) ViewDown() []string {
 		return nil
 	}
 
-	return m.LineDown(m.Height)
+	return m.LineDown(m.bodyHeight())
 }
 
 // ViewUp moves the view up by one height of the viewport. Basically, "page up".
@@ -152,7 +201,7 @@ func (m *Model) ViewUp() []string {
 		return nil
 	}
 
-	return m.LineUp(m.Height)
+	return m.LineUp(m.bodyHeight())
 }
 
 // HalfViewDown moves the view down by half the height of the viewport.
@@ -161,7 +210,7 @@ func (m *Model) HalfViewDown() (lines []string) {
 		return nil
 	}
 
-	return m.LineDown(m.Height / 2)
+	return m.LineDown(m.bodyHeight() / 2)
 }
 
 // HalfViewUp moves the view up by half the height of the viewport.
@@ -170,12 +219,13 @@ func (m *Model) HalfViewUp() (lines []string) {
 		return nil
 	}
 
-	return m.LineUp(m.Height / 2)
+	return m.LineUp(m.bodyHeight() / 2)
 }
 
 // LineDown moves the view down by the given number of lines.
 func (m *Model) LineDown(n int) (lines []string) {
-	if m.AtBottom() || n == 0 || len(m.lines) == 0 {
+	rows := m.layout()
+	if m.AtBottom() || n == 0 || len(rows) == 0 {
 		return nil
 	}
 
@@ -185,15 +235,16 @@ func (m *Model) LineDown(n int) (lines []string) {
 	m.SetYOffset(m.YOffset + n)
 
 	// Gather lines to send off for performance scrolling.
-	bottom := clamp(m.YOffset+m.Height, 0, len(m.lines))
-	top := clamp(m.YOffset+m.Height-n, 0, bottom)
-	return m.lines[top:bottom]
+	bottom := clamp(m.YOffset+m.bodyHeight(), 0, len(rows))
+	top := clamp(m.YOffset+m.bodyHeight()-n, 0, bottom)
+	return rowTexts(rows[top:bottom])
 }
 
 // LineUp moves the view down by the given number of lines. Returns the new
 // lines to show.
 func (m *Model) LineUp(n int) (lines []string) {
-	if m.AtTop() || n == 0 || len(m.lines) == 0 {
+	rows := m.layout()
+	if m.AtTop() || n == 0 || len(rows) == 0 {
 		return nil
 	}
 
@@ -204,7 +255,12 @@ func (m *Model) LineUp(n int) (lines []string) {
 	// Gather lines to send off for performance scrolling.
 	top := max(0, m.YOffset)
 	bottom := clamp(m.YOffset+n, 0, m.maxYOffset())
-	return m.lines[top:bottom]
+	return rowTexts(rows[top:bottom])
+}
+
+// Content returns the content of the viewport, as set by SetContent.
+func (m Model) Content() string {
+	return strings.Join(m.lines, "\n")
 }
 
 // TotalLineCount returns the total number of lines (both hidden and visible) within the viewport.
@@ -227,13 +283,32 @@ func (m *Model) GotoTop() (lines []string) {
 	return m.visibleLines()
 }
 
+// GotoLine scrolls the viewport so line n of the content is at the top, or
+// as close to it as the content allows.
+func (m *Model) GotoLine(n int) (lines []string) {
+	m.SetYOffset(m.rowOf(n))
+	return m.visibleLines()
+}
+
+// ShowLine scrolls just enough for line n of the content to be visible.
+func (m *Model) ShowLine(n int) {
+	if r := m.rowOf(n); n >= m.headerCount() && (r < m.YOffset || r >= m.YOffset+m.bodyHeight()) {
+		m.SetYOffset(r)
+	}
+}
+
+// GotoPercent scrolls the viewport so the line at p percent of the content
+// is at the top, or as close to it as the content allows.
+func (m *Model) GotoPercent(p int) (lines []string) {
+	return m.GotoLine(max(0, len(m.lines)-1) * clamp(p, 0, 100) / 100)
+}
+
 // GotoBottom sets the viewport to the bottom position.
 func (m *Model) GotoBottom() (lines []string) {
 	m.SetYOffset(m.maxYOffset())
 	return m.visibleLines()
 }
 
-
 // SetHorizontalStep is a setter for `horizontalStep`.
 // Must be set before `MoveLeft` or `MoveRight` is used.
 // If 0 or negative, left/right movement doesn't work.
@@ -254,8 +329,12 @@ func (m *Model) MoveLeft() {
 	}
 }
 
-// MoveRight moves all lines to set runes right.
+// MoveRight moves all lines to set runes right. It does nothing while
+// wrapping since every line already fits the width.
 func (m *Model) MoveRight() {
+	if m.wrap {
+		return
+	}
 	m.indent += m.horizontalStep
 }
 
@@ -277,6 +356,7 @@ func (m Model) updateAsModel(msg tea.Msg) (Model, tea.Cmd) {
 	if !m.initialized {
 		m.setInitialValues()
 	}
+	m.syncRows()
 
 	var cmd tea.Cmd
 
@@ -306,6 +386,12 @@ func (m Model) updateAsModel(msg tea.Msg) (Model, tea.Cmd) {
 
 		case key.Matches(msg, m.KeyMap.Right):
 			m.MoveRight()
+
+		case key.Matches(msg, m.KeyMap.Top):
+			m.GotoTop()
+
+		case key.Matches(msg, m.KeyMap.Bottom):
+			m.GotoBottom()
 		}
 
 	case tea.MouseWheelMsg:
@@ -340,15 +426,22 @@ func (m Model) View() string {
 		Height(contentHeight).
 		MaxHeight(contentHeight).
 		MaxWidth(contentWidth).
-		Render(strings.Join(m.visibleLines(), "\n"))
+		Render(strings.Join(m.withGutter(m.visibleLines()), "\n"))
 	return m.Style.UnsetWidth().UnsetHeight().
 		Render(contents)
 }
 
+func rowTexts(rows []row) []string {
+	texts := make([]string, len(rows))
+	for i, r := range rows {
+		texts[i] = r.text
+	}
+	return texts
+}
+
 func clamp(v, low, high int) int {
 	if high < low {
 		low, high = high, low
 	}
 	return min(high, max(low, v))
 }
-
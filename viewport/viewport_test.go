@@ -1,6 +1,10 @@
 package viewport
 
-import "testing"
+import (
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+)
 
 func newTestViewport(width, height int, content string) Model {
 	m := New(width, height)
@@ -138,3 +142,19 @@ func TestClamp(t *testing.T) {
 		}
 	}
 }
+
+func TestTopBottomKeysAndGotoPercent(t *testing.T) {
+	m := newTestViewport(20, 2, "a\nb\nc\nd\ne")
+	m, _ = m.Update(tea.KeyPressMsg{Code: 'G', Text: "G"})
+	if !m.AtBottom() {
+		t.Fatalf("expected G to go to the bottom, got YOffset=%d", m.YOffset)
+	}
+	m, _ = m.Update(tea.KeyPressMsg{Code: 'g', Text: "g"})
+	if !m.AtTop() {
+		t.Fatalf("expected g to go to the top, got YOffset=%d", m.YOffset)
+	}
+	m.GotoPercent(50)
+	if m.YOffset != 2 {
+		t.Fatalf("expected 50%% to show line 2 at the top, got %d", m.YOffset)
+	}
+}
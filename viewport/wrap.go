@@ -0,0 +1,133 @@
+package viewport
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/mattn/go-runewidth"
+
+	"github.com/fabio42/sasqwatch/sgr"
+)
+
+// row is a screen row of the viewport: a whole content line, or one segment
+// of it when wrapping is enabled.
+type row struct {
+	line int // index of the content line
+	seg  int // index of the segment within the wrapped line
+	text string
+}
+
+// SetWrap enables or disables soft wrapping of long lines. While wrapping,
+// horizontal scrolling is disabled and offsets count wrapped rows.
+func (m *Model) SetWrap(wrap bool) {
+	top := m.TopLine()
+	m.wrap = wrap
+	m.indent = 0
+	m.rows = nil
+	m.syncRows()
+	m.SetYOffset(m.rowOf(top))
+}
+
+// Wrap returns whether soft wrapping is enabled.
+func (m Model) Wrap() bool {
+	return m.wrap
+}
+
+// syncRows rebuilds the cached rows when the content or the width changed.
+func (m *Model) syncRows() {
+	m.rows = m.layout()
+	m.rowsWidth = m.Width
+}
+
+// layout returns the rows of the content, from the cache when still valid.
+func (m Model) layout() []row {
+	if m.rows != nil && m.rowsWidth == m.Width {
+		return m.rows
+	}
+	rows := make([]row, 0, len(m.lines))
+	for i := min(m.frozenRows, len(m.lines)); i < len(m.lines); i++ {
+		line := m.lines[i]
+		if !m.wrap {
+			rows = append(rows, row{line: i, text: line})
+			continue
+		}
+		for k, seg := range wrapLine(line, m.contentWidth()) {
+			rows = append(rows, row{line: i, seg: k, text: seg})
+		}
+	}
+	return rows
+}
+
+// contentWidth is the number of columns available to the content, once the
+// frame and the gutter are accounted for.
+func (m Model) contentWidth() int {
+	w := m.Width
+	if sw := m.Style.GetWidth(); sw != 0 {
+		w = min(w, sw)
+	}
+	return w - m.Style.GetHorizontalFrameSize() - m.gutterWidth()
+}
+
+// TopLine returns the content line shown on the first row of the scrolling
+// part of the viewport.
+func (m Model) TopLine() int {
+	rows := m.layout()
+	if len(rows) == 0 {
+		return m.headerCount()
+	}
+	return rows[clamp(m.YOffset, 0, len(rows)-1)].line
+}
+
+// rowOf returns the first row displaying content line n. Frozen lines are
+// always displayed and map to the first row.
+func (m Model) rowOf(n int) int {
+	for i, r := range m.layout() {
+		if r.line >= n {
+			return i
+		}
+	}
+	return max(0, len(m.layout())-1)
+}
+
+// wrapLine splits line into segments of at most width columns. Escape
+// sequences are kept and do not count towards the width; the SGR state is
+// closed at the end of a segment and re-opened on the next one so every
+// segment renders on its own. Wide runes are measured with runewidth and
+// never split.
+func wrapLine(line string, width int) []string {
+	if width <= 0 {
+		return []string{line}
+	}
+	var segs []string
+	var seg strings.Builder
+	var state sgr.State
+	col := 0
+	for i := 0; i < len(line); {
+		if line[i] == ansi.ESC {
+			n := sgr.SeqLen(line[i:])
+			seq := line[i : i+n]
+			seg.WriteString(seq)
+			state = state.Apply(seq)
+			i += n
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(line[i:])
+		w := runewidth.RuneWidth(r)
+		if col+w > width && col > 0 {
+			style := state.String()
+			if style != "" {
+				seg.WriteString(ansi.ResetStyle)
+			}
+			segs = append(segs, seg.String())
+			seg.Reset()
+			seg.WriteString(style)
+			col = 0
+		}
+		seg.WriteString(line[i : i+size])
+		col += w
+		i += size
+	}
+	return append(segs, seg.String())
+}
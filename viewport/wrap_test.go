@@ -0,0 +1,54 @@
+package viewport
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestWrapLine_KeepsStylePerSegment(t *testing.T) {
+	segs := wrapLine("\x1b[31mabcdef\x1b[0m", 4)
+	if len(segs) != 2 {
+		t.Fatalf("expected 2 segments, got %q", segs)
+	}
+	if segs[0] != "\x1b[31mabcd"+ansi.ResetStyle || segs[1] != "\x1b[31mef\x1b[0m" {
+		t.Fatalf("unexpected segments %q", segs)
+	}
+}
+
+func TestWrapLine_WideRunesNotSplit(t *testing.T) {
+	segs := wrapLine("ab漢字", 3)
+	if len(segs) != 3 || segs[0] != "ab" || segs[1] != "漢" || segs[2] != "字" {
+		t.Fatalf("unexpected segments %q", segs)
+	}
+}
+
+func TestSetWrap_ScrollsOverWrappedRows(t *testing.T) {
+	m := newTestViewport(4, 2, "aaaaaaaa\nb\nc")
+	m.SetWrap(true)
+
+	if got := len(m.layout()); got != 4 {
+		t.Fatalf("expected 4 wrapped rows, got %d", got)
+	}
+	m.GotoBottom()
+	if m.YOffset != 2 {
+		t.Fatalf("expected YOffset=2 at bottom of wrapped rows, got %d", m.YOffset)
+	}
+	if m.ScrollPercent() != 1.0 {
+		t.Fatalf("expected ScrollPercent=1.0 at bottom, got %f", m.ScrollPercent())
+	}
+	m.GotoLine(1)
+	if m.YOffset != 2 {
+		t.Fatalf("expected line 1 on wrapped row 2, got %d", m.YOffset)
+	}
+}
+
+func TestSetWrap_DisablesHorizontalScroll(t *testing.T) {
+	m := newTestViewport(4, 2, "aaaaaaaa")
+	m.MoveRight()
+	m.SetWrap(true)
+	m.MoveRight()
+	if m.indent != 0 {
+		t.Fatalf("expected no indent while wrapping, got %d", m.indent)
+	}
+}
@@ -0,0 +1,586 @@
+// Package vterm implements a minimal virtual terminal. Output written to a
+// Terminal is interpreted like a terminal emulator would: cursor movements,
+// carriage returns, erasures, scroll regions and the alternate screen update
+// a screen buffer instead of being printed verbatim. String returns the final
+// state of the screen, preceded by the lines that scrolled off its top.
+package vterm
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/mattn/go-runewidth"
+
+	"github.com/fabio42/sasqwatch/sgr"
+)
+
+const tabWidth = 8
+
+// cell is a character of the screen. The zero value is a blank.
+type cell struct {
+	ch    string // rune and its combining marks, empty when blank
+	style string // SGR sequences in effect when the cell was written
+	cont  bool   // right half of a wide rune
+}
+
+// Terminal is a virtual terminal of a fixed size.
+type Terminal struct {
+	// MaxScrollback is the number of lines kept in the scrollback, the
+	// oldest being dropped first. 0 keeps them all.
+	MaxScrollback int
+
+	cols, rows int
+	screen     [][]cell
+	scrollback [][]cell
+	dropped    int
+
+	main     [][]cell // main screen while the alternate screen is active
+	alt      bool
+	altShown [][]cell // alternate screen as it was when last left
+
+	x, y     int
+	wrapNext bool      // the cursor is past the last column, wrap on next rune
+	top, bot int       // scroll region, inclusive
+	pen      sgr.State // graphic rendition of the runes written
+	style    string    // pen as an SGR sequence
+
+	savedX, savedY int
+	savedPen       sgr.State
+
+	pending []byte // incomplete sequence left over by the last Write
+	skip    byte   // introducer of the overlong sequence being discarded, or 0
+}
+
+// maxPending caps the incomplete sequence kept between writes. A longer one,
+// such as an unterminated string or an inline image, is discarded up to its
+// end instead.
+const maxPending = 64 << 10
+
+// New returns a blank terminal of cols columns and rows rows.
+func New(cols, rows int) *Terminal {
+	t := &Terminal{cols: max(cols, 1), rows: max(rows, 1)}
+	t.screen = t.blankScreen()
+	t.bot = t.rows - 1
+	return t
+}
+
+// Write interprets p. Sequences split across calls are completed by the next
+// call. It never fails.
+func (t *Terminal) Write(p []byte) (int, error) {
+	buf := append(t.pending, p...)
+	i := 0
+	if t.skip != 0 {
+		i = t.skipSequence(buf)
+	}
+loop:
+	for i < len(buf) {
+		switch b := buf[i]; {
+		case b == ansi.ESC:
+			n, ok := t.escape(buf[i:])
+			if !ok {
+				break loop
+			}
+			i += n
+		case b < 0x20 || b == ansi.DEL:
+			t.control(b)
+			i++
+		default:
+			if !utf8.FullRune(buf[i:]) {
+				break loop
+			}
+			r, size := utf8.DecodeRune(buf[i:])
+			t.put(r)
+			i += size
+		}
+	}
+	rest := buf[i:]
+	if len(rest) > maxPending {
+		t.skip, rest = rest[1], nil
+	}
+	t.pending = append([]byte(nil), rest...)
+	return len(p), nil
+}
+
+// skipSequence returns the length of the start of buf that belongs to the
+// sequence being discarded, and stops discarding once its end is found.
+func (t *Terminal) skipSequence(buf []byte) int {
+	for i, b := range buf {
+		switch {
+		case t.skip == '[' && b >= 0x40 && b <= 0x7e, t.skip != '[' && b == ansi.BEL:
+			t.skip = 0
+			return i + 1
+		case t.skip != '[' && b == ansi.ESC:
+			// The string terminator, left to escape.
+			t.skip = 0
+			return i
+		}
+	}
+	return len(buf)
+}
+
+// String returns the lines scrolled off the screen followed by the screen,
+// without trailing blanks. A program that drew on the alternate screen and
+// left nothing on the main one is rendered as its last alternate screen.
+func (t *Terminal) String() string {
+	lines := append(append([][]cell(nil), t.scrollback...), t.screen...)
+	if t.alt {
+		lines = t.screen
+	} else if t.altShown != nil && blank(lines) {
+		lines = t.altShown
+	}
+
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		out[i] = render(line)
+	}
+	for len(out) > 0 && out[len(out)-1] == "" {
+		out = out[:len(out)-1]
+	}
+	return strings.Join(out, "\n")
+}
+
+// Resize changes the size of the terminal, as a terminal window being
+// resized. Lines are cut or padded on the right; when the screen loses rows,
+// the lines above the cursor leave it first, the others are cut at the
+// bottom. The scroll region is reset.
+func (t *Terminal) Resize(cols, rows int) {
+	cols, rows = max(cols, 1), max(rows, 1)
+	drop := max(0, t.y-(rows-1))
+	if !t.alt {
+		t.pushScrollback(t.screen[:drop])
+	}
+	t.cols = cols
+	t.screen = t.resizeScreen(t.screen[drop:], rows)
+	if t.main != nil {
+		t.main = t.resizeScreen(t.main, rows)
+	}
+	t.rows = rows
+	t.top, t.bot = 0, rows-1
+	t.moveTo(t.x, t.y-drop)
+}
+
+// resizeScreen returns screen with rows lines of t.cols cells.
+func (t *Terminal) resizeScreen(screen [][]cell, rows int) [][]cell {
+	out := make([][]cell, rows)
+	for i := range out {
+		out[i] = t.blankLine()
+		if i < len(screen) {
+			copy(out[i], screen[i])
+		}
+	}
+	return out
+}
+
+func render(line []cell) string {
+	end := len(line)
+	for end > 0 && line[end-1] == (cell{}) {
+		end--
+	}
+	var sb strings.Builder
+	style := ""
+	for _, c := range line[:end] {
+		if c.cont {
+			continue
+		}
+		if c.style != style {
+			if style != "" {
+				sb.WriteString(ansi.ResetStyle)
+			}
+			sb.WriteString(c.style)
+			style = c.style
+		}
+		if c.ch == "" {
+			sb.WriteByte(' ')
+		} else {
+			sb.WriteString(c.ch)
+		}
+	}
+	if style != "" {
+		sb.WriteString(ansi.ResetStyle)
+	}
+	return sb.String()
+}
+
+func blank(lines [][]cell) bool {
+	for _, line := range lines {
+		for _, c := range line {
+			if c != (cell{}) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (t *Terminal) blankLine() []cell {
+	return make([]cell, t.cols)
+}
+
+func (t *Terminal) blankScreen() [][]cell {
+	screen := make([][]cell, t.rows)
+	for i := range screen {
+		screen[i] = t.blankLine()
+	}
+	return screen
+}
+
+// put writes r at the cursor and advances it, wrapping at the right margin.
+func (t *Terminal) put(r rune) {
+	w := runewidth.RuneWidth(r)
+	if w == 0 {
+		// Combining mark: attach it to the previous character.
+		if x := t.x - 1; x >= 0 && !t.wrapNext {
+			t.screen[t.y][x].ch += string(r)
+		} else if t.wrapNext {
+			t.screen[t.y][t.x].ch += string(r)
+		}
+		return
+	}
+	if t.wrapNext || (w == 2 && t.x == t.cols-1) {
+		t.x = 0
+		t.lineFeed()
+	}
+	t.wrapNext = false
+	t.screen[t.y][t.x] = cell{ch: string(r), style: t.style}
+	if w == 2 && t.x+1 < t.cols {
+		t.screen[t.y][t.x+1] = cell{style: t.style, cont: true}
+	}
+	t.x += w
+	if t.x >= t.cols {
+		t.x = t.cols - 1
+		t.wrapNext = true
+	}
+}
+
+func (t *Terminal) control(b byte) {
+	switch b {
+	case '\r':
+		t.moveTo(0, t.y)
+	case '\n', '\v', '\f':
+		t.wrapNext = false
+		t.lineFeed()
+	case '\b':
+		t.moveTo(t.x-1, t.y)
+	case '\t':
+		t.moveTo((t.x/tabWidth+1)*tabWidth, t.y)
+	}
+}
+
+// lineFeed moves the cursor down, scrolling the region at its bottom.
+func (t *Terminal) lineFeed() {
+	switch {
+	case t.y == t.bot:
+		t.scrollUp(1)
+	case t.y < t.rows-1:
+		t.y++
+	}
+}
+
+// reverseIndex moves the cursor up, scrolling the region at its top.
+func (t *Terminal) reverseIndex() {
+	switch {
+	case t.y == t.top:
+		t.scrollDown(1)
+	case t.y > 0:
+		t.y--
+	}
+}
+
+// scrollUp scrolls the region up by n lines. Lines leaving a region that
+// starts at the top of the main screen are kept in the scrollback.
+func (t *Terminal) scrollUp(n int) {
+	n = clamp(n, 0, t.bot-t.top+1)
+	if t.top == 0 && !t.alt {
+		t.pushScrollback(t.screen[:n])
+	}
+	region := t.screen[t.top : t.bot+1]
+	kept := append([][]cell(nil), region[n:]...)
+	for i := 0; i < n; i++ {
+		kept = append(kept, t.blankLine())
+	}
+	copy(region, kept)
+}
+
+// pushScrollback appends lines to the scrollback, dropping its oldest lines
+// past MaxScrollback.
+func (t *Terminal) pushScrollback(lines [][]cell) {
+	t.scrollback = append(t.scrollback, lines...)
+	if n := len(t.scrollback) - t.MaxScrollback; t.MaxScrollback > 0 && n > 0 {
+		t.scrollback = t.scrollback[n:]
+		t.dropped += n
+	}
+}
+
+// Dropped returns the number of lines dropped from the scrollback because of
+// MaxScrollback.
+func (t *Terminal) Dropped() int {
+	return t.dropped
+}
+
+// scrollDown scrolls the region down by n lines.
+func (t *Terminal) scrollDown(n int) {
+	n = clamp(n, 0, t.bot-t.top+1)
+	region := t.screen[t.top : t.bot+1]
+	moved := make([][]cell, 0, len(region))
+	for i := 0; i < n; i++ {
+		moved = append(moved, t.blankLine())
+	}
+	moved = append(moved, region[:len(region)-n]...)
+	copy(region, moved)
+}
+
+func (t *Terminal) moveTo(x, y int) {
+	t.x = clamp(x, 0, t.cols-1)
+	t.y = clamp(y, 0, t.rows-1)
+	t.wrapNext = false
+}
+
+// escape handles the escape sequence at the start of seq and returns its
+// length, or false when seq ends before the sequence does.
+func (t *Terminal) escape(seq []byte) (int, bool) {
+	if len(seq) < 2 {
+		return 0, false
+	}
+	switch seq[1] {
+	case '[':
+		for i := 2; i < len(seq); i++ {
+			if seq[i] >= 0x40 && seq[i] <= 0x7e {
+				t.csi(string(seq[2:i]), seq[i])
+				return i + 1, true
+			}
+		}
+		return 0, false
+	case ']', 'P', '_', '^', 'X':
+		// OSC, DCS and other strings have no effect on the screen.
+		for i := 2; i < len(seq); i++ {
+			if seq[i] == ansi.BEL {
+				return i + 1, true
+			}
+			if seq[i] == ansi.ESC && i+1 < len(seq) && seq[i+1] == '\\' {
+				return i + 2, true
+			}
+		}
+		return 0, false
+	case '(', ')', '*', '+':
+		// Character set designation.
+		if len(seq) < 3 {
+			return 0, false
+		}
+		return 3, true
+	case '7':
+		t.saveCursor()
+	case '8':
+		t.restoreCursor()
+	case 'D':
+		t.lineFeed()
+	case 'E':
+		t.x = 0
+		t.lineFeed()
+	case 'M':
+		t.reverseIndex()
+	case 'c':
+		// Full reset: the screen is cleared but the limits set on the
+		// terminal and what they dropped are kept.
+		*t = Terminal{MaxScrollback: t.MaxScrollback, dropped: t.dropped, cols: t.cols, rows: t.rows}
+		t.screen = t.blankScreen()
+		t.bot = t.rows - 1
+	}
+	return 2, true
+}
+
+func (t *Terminal) saveCursor() {
+	t.savedX, t.savedY, t.savedPen = t.x, t.y, t.pen
+}
+
+func (t *Terminal) restoreCursor() {
+	t.moveTo(t.savedX, t.savedY)
+	t.setPen(t.savedPen)
+}
+
+func (t *Terminal) setPen(pen sgr.State) {
+	t.pen, t.style = pen, pen.String()
+}
+
+// csi handles a control sequence with its parameter bytes and final byte.
+func (t *Terminal) csi(params string, final byte) {
+	if strings.HasPrefix(params, "?") {
+		t.privateMode(params[1:], final)
+		return
+	}
+	args := parseArgs(params)
+	n := arg(args, 0, 1)
+	switch final {
+	case 'A':
+		t.moveTo(t.x, t.y-n)
+	case 'B', 'e':
+		t.moveTo(t.x, t.y+n)
+	case 'C', 'a':
+		t.moveTo(t.x+n, t.y)
+	case 'D':
+		t.moveTo(t.x-n, t.y)
+	case 'E':
+		t.moveTo(0, t.y+n)
+	case 'F':
+		t.moveTo(0, t.y-n)
+	case 'G', '`':
+		t.moveTo(n-1, t.y)
+	case 'd':
+		t.moveTo(t.x, n-1)
+	case 'H', 'f':
+		t.moveTo(arg(args, 1, 1)-1, n-1)
+	case 'J':
+		t.eraseDisplay(arg(args, 0, 0))
+	case 'K':
+		t.eraseLine(arg(args, 0, 0))
+	case 'X':
+		t.clearCells(t.y, t.x, t.x+n)
+	case '@':
+		line := t.screen[t.y]
+		n = clamp(n, 1, t.cols-t.x)
+		copy(line[t.x+n:], line[t.x:])
+		t.clearCells(t.y, t.x, t.x+n)
+	case 'P':
+		line := t.screen[t.y]
+		n = clamp(n, 1, t.cols-t.x)
+		copy(line[t.x:], line[t.x+n:])
+		t.clearCells(t.y, t.cols-n, t.cols)
+	case 'L', 'M':
+		if t.y < t.top || t.y > t.bot {
+			return
+		}
+		top := t.top
+		t.top = t.y
+		if final == 'L' {
+			t.scrollDown(n)
+		} else {
+			t.scrollRegionUp(n)
+		}
+		t.top = top
+		t.x = 0
+	case 'S':
+		t.scrollRegionUp(n)
+	case 'T':
+		t.scrollDown(n)
+	case 'r':
+		top, bot := arg(args, 0, 1)-1, arg(args, 1, t.rows)-1
+		if top < bot && bot < t.rows {
+			t.top, t.bot = top, bot
+		}
+		t.moveTo(0, 0)
+	case 'm':
+		t.setPen(t.pen.ApplyParams(params))
+	case 's':
+		t.saveCursor()
+	case 'u':
+		t.restoreCursor()
+	}
+}
+
+// scrollRegionUp scrolls the region up without feeding the scrollback, as
+// for deleted lines.
+func (t *Terminal) scrollRegionUp(n int) {
+	alt := t.alt
+	t.alt = true
+	t.scrollUp(n)
+	t.alt = alt
+}
+
+// privateMode handles DECSET and DECRST; only the alternate screen matters.
+func (t *Terminal) privateMode(params string, final byte) {
+	if final != 'h' && final != 'l' {
+		return
+	}
+	for _, mode := range parseArgs(params) {
+		if mode != 1049 && mode != 1047 && mode != 47 {
+			continue
+		}
+		switch {
+		case final == 'h' && !t.alt:
+			if mode == 1049 {
+				t.saveCursor()
+			}
+			t.main, t.screen, t.alt = t.screen, t.blankScreen(), true
+		case final == 'l' && t.alt:
+			t.altShown, t.screen, t.alt = t.screen, t.main, false
+			if mode == 1049 {
+				t.restoreCursor()
+			}
+		}
+	}
+}
+
+func (t *Terminal) eraseDisplay(mode int) {
+	switch mode {
+	case 0:
+		t.clearCells(t.y, t.x, t.cols)
+		for y := t.y + 1; y < t.rows; y++ {
+			t.screen[y] = t.blankLine()
+		}
+	case 1:
+		t.clearCells(t.y, 0, t.x+1)
+		for y := 0; y < t.y; y++ {
+			t.screen[y] = t.blankLine()
+		}
+	case 2:
+		t.screen = t.blankScreen()
+	case 3:
+		t.screen = t.blankScreen()
+		t.scrollback = nil
+	}
+}
+
+func (t *Terminal) eraseLine(mode int) {
+	switch mode {
+	case 0:
+		t.clearCells(t.y, t.x, t.cols)
+	case 1:
+		t.clearCells(t.y, 0, t.x+1)
+	case 2:
+		t.clearCells(t.y, 0, t.cols)
+	}
+}
+
+func (t *Terminal) clearCells(y, from, to int) {
+	line := t.screen[y]
+	for x := max(from, 0); x < min(to, t.cols); x++ {
+		line[x] = cell{}
+	}
+}
+
+// maxArg caps the numeric parameters of control sequences, as xterm does,
+// so arithmetic on them cannot overflow.
+const maxArg = 1<<16 - 1
+
+// parseArgs returns the numeric parameters of a control sequence. Missing
+// parameters are 0, as are negative or invalid ones, so they take their
+// default; sub-parameters and intermediate bytes are ignored.
+func parseArgs(params string) []int {
+	if params == "" {
+		return nil
+	}
+	fields := strings.Split(params, ";")
+	args := make([]int, len(fields))
+	for i, f := range fields {
+		f, _, _ = strings.Cut(f, ":")
+		v, err := strconv.Atoi(strings.TrimRight(f, " !\"#$%&'()*+,-./"))
+		if err != nil || v < 0 {
+			v = 0
+		}
+		args[i] = min(v, maxArg)
+	}
+	return args
+}
+
+// arg returns args[i], or def when it is missing or 0.
+func arg(args []int, i, def int) int {
+	if i >= len(args) || args[i] == 0 {
+		return def
+	}
+	return args[i]
+}
+
+func clamp(v, low, high int) int {
+	return min(high, max(low, v))
+}
@@ -0,0 +1,159 @@
+package vterm
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func screen(cols, rows int, out string) string {
+	t := New(cols, rows)
+	t.Write([]byte(out))
+	return t.String()
+}
+
+func TestCarriageReturnOverwrites(t *testing.T) {
+	got := screen(20, 3, "progress 10%\rprogress 100%\r\ndone\r\n")
+	if got != "progress 100%\ndone" {
+		t.Fatalf("unexpected screen %q", got)
+	}
+}
+
+func TestCursorMovementAndErase(t *testing.T) {
+	got := screen(10, 3, "aaaa\r\nbbbb\r\ncccc\x1b[2;2H\x1b[K\x1b[1;3HX")
+	if got != "aaXa\nb\ncccc" {
+		t.Fatalf("unexpected screen %q", got)
+	}
+}
+
+func TestScrollbackKeepsLongOutput(t *testing.T) {
+	got := screen(10, 2, "1\r\n2\r\n3\r\n4")
+	if got != "1\n2\n3\n4" {
+		t.Fatalf("expected lines scrolled off the screen to be kept, got %q", got)
+	}
+}
+
+func TestMaxScrollback(t *testing.T) {
+	term := New(10, 2)
+	term.MaxScrollback = 1
+	term.Write([]byte("1\r\n2\r\n3\r\n4"))
+	if got := term.String(); got != "2\n3\n4" {
+		t.Fatalf("expected the oldest line to be dropped, got %q", got)
+	}
+	if term.Dropped() != 1 {
+		t.Fatalf("expected 1 dropped line, got %d", term.Dropped())
+	}
+}
+
+func TestAutowrap(t *testing.T) {
+	got := screen(3, 3, "abcdef")
+	if got != "abc\ndef" {
+		t.Fatalf("unexpected screen %q", got)
+	}
+}
+
+func TestStylesPerCell(t *testing.T) {
+	got := screen(10, 1, "\x1b[31mred\x1b[0m ok")
+	want := "\x1b[31mred" + ansi.ResetStyle + " ok"
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestAlternateScreen(t *testing.T) {
+	// A full screen program that leaves the alternate screen on exit keeps
+	// its last frame when it printed nothing else.
+	got := screen(10, 2, "\x1b[?1049h\x1b[Hframe1\x1b[Hframe2\x1b[?1049l")
+	if got != "frame2" {
+		t.Fatalf("unexpected screen %q", got)
+	}
+	got = screen(10, 2, "before\r\n\x1b[?1049hfull\x1b[?1049l")
+	if got != "before" {
+		t.Fatalf("expected the main screen to be restored, got %q", got)
+	}
+}
+
+func TestSequenceSplitAcrossWrites(t *testing.T) {
+	term := New(10, 1)
+	term.Write([]byte("ab\x1b[1"))
+	term.Write([]byte("Dx\xe2\x82"))
+	term.Write([]byte("\xac"))
+	if got := term.String(); got != "ax€" {
+		t.Fatalf("unexpected screen %q", got)
+	}
+}
+
+func TestOverlongSequenceIsDiscarded(t *testing.T) {
+	term := New(10, 1)
+	term.Write([]byte("a\x1b]52;c;"))
+	chunk := bytes.Repeat([]byte("x"), 4096)
+	for range 2 * maxPending / len(chunk) {
+		term.Write(chunk)
+		if len(term.pending) > maxPending {
+			t.Fatalf("expected the pending sequence to be capped, got %d bytes", len(term.pending))
+		}
+	}
+	term.Write([]byte("xx\x1b\\b\x1b[3"))
+	term.Write(bytes.Repeat([]byte("1"), 2*maxPending))
+	term.Write([]byte("1mc"))
+	if got := term.String(); got != "abc" {
+		t.Fatalf("unexpected screen %q", got)
+	}
+}
+
+func TestResetKeepsLimits(t *testing.T) {
+	term := New(10, 1)
+	term.MaxScrollback = 1
+	term.Write([]byte("1\r\n2\r\n3\r\n4"))
+	term.Write([]byte("\x1bc5\r\n6\r\n7"))
+	if term.MaxScrollback != 1 || term.Dropped() != 3 {
+		t.Fatalf("expected the limit to survive the reset, got MaxScrollback=%d Dropped=%d", term.MaxScrollback, term.Dropped())
+	}
+	if got := term.String(); got != "6\n7" {
+		t.Fatalf("unexpected screen %q", got)
+	}
+}
+
+func TestMalformedArgumentsTakeTheirDefault(t *testing.T) {
+	for _, seq := range []string{
+		"\x1b[-5@", "ab\x1b[-5P", "\x1b[-3L", "\x1b[-3M", "\x1b[-2S", "\x1b[-2T",
+		"\x1b[-4;-1r", "\x1b[99999999999999999999P", "\x1b[9223372036854775807@",
+		"\x1b[9223372036854775807C\x1b[9223372036854775807X", "\x1b[3;2r\x1b[2H\x1b[-1L",
+	} {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Fatalf("%q: unexpected panic: %v", seq, r)
+				}
+			}()
+			screen(10, 3, "xyz\r\n"+seq+"ok")
+		}()
+	}
+	if got := screen(10, 1, "abcd\x1b[2G\x1b[-5P"); got != "acd" {
+		t.Fatalf("expected a negative count to delete one character, got %q", got)
+	}
+}
+
+func TestWideRunes(t *testing.T) {
+	got := screen(3, 2, "a漢字")
+	if got != "a漢\n字" {
+		t.Fatalf("unexpected screen %q", got)
+	}
+}
+
+func TestResize(t *testing.T) {
+	term := New(6, 3)
+	term.Write([]byte("ab\r\n123456\r\nxy"))
+	term.Resize(4, 2)
+	term.Write([]byte("z"))
+	// The first line went to the scrollback and keeps its width.
+	if got := term.String(); got != "ab\n1234\nxyz" {
+		t.Fatalf("unexpected screen after shrinking %q", got)
+	}
+	term.Resize(8, 3)
+	term.Write([]byte("\r\n12345678"))
+	if got := term.String(); got != "ab\n1234\nxyz\n12345678" {
+		t.Fatalf("unexpected screen after growing %q", got)
+	}
+}